@@ -3,61 +3,78 @@ package cases
 import (
 	"errors"
 	"math/big"
-	"time"
 
 	"github.com/bnb-chain/bsc-mev-cases/log"
 )
 
-var abcCases = map[string]BidCaseFn{
-	"ValidBid_NilPayBidTx_ABC1":   ValidBid_NilPayBidTx_ABC1,
-	"ValidBid_NilPayBidTx_ABC200": ValidBid_NilPayBidTx_ABC200,
+func init() {
+	Register(&CaseInfo{
+		Name:          "ValidBid_NilPayBidTx_ABC1",
+		Description:   "bid of 1 ABC transfer without PayBidTx",
+		Tags:          []Tag{TagABC},
+		Expect:        ExpectTxsSucceed,
+		EstimatedCost: 0.002162,
+		Fn:            ValidBid_NilPayBidTx_ABC1,
+	})
+	Register(&CaseInfo{
+		Name:          "ValidBid_NilPayBidTx_ABC200",
+		Description:   "bid of 200 ABC transfers without PayBidTx",
+		Tags:          []Tag{TagABC},
+		Expect:        ExpectTxsSucceed,
+		EstimatedCost: 0.4324,
+		Fn:            ValidBid_NilPayBidTx_ABC200,
+	})
 }
 
 // ValidBid_NilPayBidTx_ABC1
-// gasFee = 21000 * 200 * 0.0000001 BNB = 0.42 BNB
+// gasFee = 21620 * 1 * 0.0000001 BNB = 0.002162 BNB
 func ValidBid_NilPayBidTx_ABC1(arg *BidCaseArg) error {
 	txs := generateABCTxs(arg, big.NewInt(1e16), 1)
-	gasUsed := ABCGasUsed * 1
-	gasFee := big.NewInt(gasUsed * DefaultABCGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	acc, err := simulateBid(arg, txs)
+	if err != nil {
+		return err
+	}
+	gasUsed, gasFee := int64(acc.GasUsed()), acc.GasFee()
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
 
-	_, err := arg.Client.SendBid(arg.Ctx, *bidArgs)
+	_, err = arg.sendBid(bidArgs)
 	if err != nil {
 		log.Errorw("not expect error", "err", err)
 		return errors.New("not expect error")
 	}
 
-	time.Sleep(5 * time.Second)
-
-	for _, tx := range txs {
-		receipt, err := arg.Client.TransactionReceipt(arg.Ctx, tx.Hash())
-		if err != nil {
-			log.Errorw("Client.TransactionReceipt", "err", err)
-			return err
-		}
-
-		if receipt.Status != 1 {
-			err = errors.New("tx failed")
-			return err
-		}
+	receipts, err := arg.waiter().WaitForBid(arg.Ctx, bidArgs)
+	if err != nil {
+		log.Errorw("waiter.WaitForBid", "err", err)
+		return err
 	}
 
-	return nil
-
+	return assertReceiptsSucceed(receipts)
 }
 
 // ValidBid_NilPayBidTx_ABC200
-// gasFee = 21000 * 200 * 0.0000001 BNB = 0.42 BNB
+// gasFee = 21620 * 200 * 0.0000001 BNB = 0.4324 BNB
 func ValidBid_NilPayBidTx_ABC200(arg *BidCaseArg) error {
 	txs := generateABCTxs(arg, big.NewInt(1e16), 200)
-	gasUsed := ABCGasUsed * 200
-	gasFee := big.NewInt(gasUsed * DefaultABCGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	acc, err := simulateBid(arg, txs)
+	if err != nil {
+		return err
+	}
+	gasUsed, gasFee := int64(acc.GasUsed()), acc.GasFee()
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertTxSucceed(arg.Ctx, arg.Client, bidArgs, txs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		retry, err = assertTxSucceed(arg.Ctx, arg.Client, bidArgs, txs)
+	retry, err := assertTxSucceed(arg, bidArgs, txs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertTxSucceed(arg, bidArgs, txs)
 	}
 	return err
 
@@ -2,15 +2,14 @@ package cases
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/bnb-chain/bsc-mev-cases/abc"
 	"github.com/bnb-chain/bsc-mev-cases/log"
@@ -24,25 +23,31 @@ var (
 )
 
 type Account struct {
-	Address    common.Address
-	privateKey *ecdsa.PrivateKey
-	Nonce      uint64
-	abc        *abc.Abc
+	Address common.Address
+	signer  Signer
+	Nonce   uint64
+	abc     *abc.Abc
 }
 
-func NewAccount(privateKey string, abc *abc.Abc) *Account {
-	privateECDSAKey, address := PriKeyToAddress(privateKey)
+// NewAccount creates an account of the signer, with its pending nonce read from the full node.
+func NewAccount(ctx context.Context, client *ethclient.Client, signer Signer, abc *abc.Abc) *Account {
+	account := newAccount(signer, abc)
 
-	nonce, err := fullNode.PendingNonceAt(context.TODO(), address)
+	nonce, err := client.PendingNonceAt(ctx, account.Address)
 	if err != nil {
 		log.Errorw("failed to get pending Nonce", "err", err)
 	}
 
+	account.Nonce = nonce
+	return account
+}
+
+// newAccount creates an account of the signer, its nonces are allocated by Nonces.
+func newAccount(signer Signer, abc *abc.Abc) *Account {
 	return &Account{
-		Address:    address,
-		privateKey: privateECDSAKey,
-		Nonce:      nonce,
-		abc:        abc,
+		Address: signer.Address(),
+		signer:  signer,
+		abc:     abc,
 	}
 }
 
@@ -56,7 +61,7 @@ func (a *Account) TransferBNB(nonce uint64, toAddress common.Address, chainID *b
 		Data:     nil,
 	})
 
-	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), a.privateKey)
+	signedTx, err := a.signer.SignTx(tx, chainID)
 	if err != nil {
 		log.Errorw("failed to sign tx", "err", err)
 		return nil, err
@@ -75,7 +80,64 @@ func (a *Account) TransferBNBWithHighGas(nonce uint64, toAddress common.Address,
 		Data:     nil,
 	})
 
-	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), a.privateKey)
+	signedTx, err := a.signer.SignTx(tx, chainID)
+	if err != nil {
+		log.Errorw("failed to sign tx", "err", err)
+		return nil, err
+	}
+
+	return signedTx, nil
+}
+
+// TxOptions sets the type and fees of a tx.
+type TxOptions struct {
+	// Type is types.LegacyTxType, types.AccessListTxType or types.DynamicFeeTxType
+	Type uint8
+	// GasPrice is the gas price of legacy and access list txs, and the fee cap of dynamic fee txs
+	GasPrice *big.Int
+	// GasTipCap is the tip cap of dynamic fee txs
+	GasTipCap  *big.Int
+	AccessList types.AccessList
+}
+
+// TransferBNBWithOptions creates a BNB transfer tx of the type and fees in options.
+func (a *Account) TransferBNBWithOptions(nonce uint64, toAddress common.Address, chainID *big.Int, amount *big.Int, opts TxOptions) (*types.Transaction, error) {
+	var data types.TxData
+	switch opts.Type {
+	case types.LegacyTxType:
+		data = &types.LegacyTx{
+			Nonce:    nonce,
+			To:       &toAddress,
+			Value:    amount,
+			Gas:      DefaultGasLimit,
+			GasPrice: opts.GasPrice,
+		}
+	case types.AccessListTxType:
+		data = &types.AccessListTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			To:         &toAddress,
+			Value:      amount,
+			Gas:        DefaultGasLimit,
+			GasPrice:   opts.GasPrice,
+			AccessList: opts.AccessList,
+		}
+	case types.DynamicFeeTxType:
+		data = &types.DynamicFeeTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			To:         &toAddress,
+			Value:      amount,
+			Gas:        DefaultGasLimit,
+			GasFeeCap:  opts.GasPrice,
+			GasTipCap:  opts.GasTipCap,
+			AccessList: opts.AccessList,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported tx type %v", opts.Type)
+	}
+
+	signedTx, err := a.signer.SignTx(types.NewTx(data), chainID)
 	if err != nil {
 		log.Errorw("failed to sign tx", "err", err)
 		return nil, err
@@ -98,12 +160,7 @@ func (a *Account) TransferBNBNoSign(nonce uint64, toAddress common.Address, chai
 }
 
 func (a *Account) TransferABC(nonce uint64, toAddress common.Address, chainID *big.Int, amount *big.Int) (*types.Transaction, error) {
-	auth, err := bind.NewKeyedTransactorWithChainID(a.privateKey, chainID)
-	if err != nil {
-		log.Errorw("failed to create transactor", "err", err)
-		return nil, err
-	}
-
+	auth := a.TransactOpts(chainID)
 	auth.Nonce = big.NewInt(int64(nonce))
 	auth.GasLimit = DefaultGasLimit
 	auth.GasPrice = DefaultABCGasPrice
@@ -112,15 +169,24 @@ func (a *Account) TransferABC(nonce uint64, toAddress common.Address, chainID *b
 	return a.abc.Transfer(auth, toAddress, amount)
 }
 
-func (a *Account) SignBid(rawBid *types.RawBid) *types.BidArgs {
-	data, err := rlp.EncodeToBytes(rawBid)
-	if err != nil {
-		log.Errorw("failed to encode raw bid", "err", err)
+// TransactOpts returns the options of contract calls signed by the account.
+func (a *Account) TransactOpts(chainID *big.Int) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From: a.Address,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != a.Address {
+				return nil, bind.ErrNotAuthorized
+			}
+			return a.signer.SignTx(tx, chainID)
+		},
+		Context: context.Background(),
 	}
+}
 
-	sig, err := crypto.Sign(crypto.Keccak256(data), a.privateKey)
+func (a *Account) SignBid(rawBid *types.RawBid) (*types.BidArgs, error) {
+	sig, err := a.signer.SignBid(rawBid)
 	if err != nil {
-		log.Errorw("failed to sign raw bid", "err", err)
+		return nil, fmt.Errorf("failed to sign raw bid, %v", err)
 	}
 
 	bidArgs := types.BidArgs{
@@ -128,30 +194,28 @@ func (a *Account) SignBid(rawBid *types.RawBid) *types.BidArgs {
 		Signature: sig,
 	}
 
-	return &bidArgs
+	return &bidArgs, nil
 }
 
-func (a *Account) PayBidTx(receiver common.Address, chainID *big.Int, amount *big.Int) []byte {
+func (a *Account) PayBidTx(nonce uint64, receiver common.Address, chainID *big.Int, amount *big.Int) ([]byte, error) {
 	tx := types.NewTx(&types.LegacyTx{
-		Nonce:    a.Nonce,
+		Nonce:    nonce,
 		GasPrice: big.NewInt(0),
 		Gas:      25000,
 		To:       &receiver,
 		Value:    amount,
 	})
 
-	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), a.privateKey)
-	for err != nil {
-		log.Errorw("failed to sign tx", "err", err)
-		signedTx, err = types.SignTx(tx, types.LatestSignerForChainID(chainID), a.privateKey)
+	signedTx, err := a.signer.SignPayBidTx(tx, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign PayBidTx, %v", err)
 	}
 
-	txByte, _ := signedTx.MarshalBinary()
-	return txByte
+	return signedTx.MarshalBinary()
 }
 
-func (a *Account) BalanceBNB(client *ethclient.Client) *big.Int {
-	balance, err := fullNode.BalanceAt(context.TODO(), a.Address, nil)
+func (a *Account) BalanceBNB(ctx context.Context, client *ethclient.Client) *big.Int {
+	balance, err := client.BalanceAt(ctx, a.Address, nil)
 	if err != nil {
 		log.Errorw("Client.BalanceAt", "err", err)
 	}
@@ -168,32 +232,191 @@ func (a *Account) BalanceABC() *big.Int {
 	return balance
 }
 
-func PriKeyToAddress(privateKey string) (*ecdsa.PrivateKey, common.Address) {
-	privateECDSAKey, err := crypto.HexToECDSA(privateKey)
+type BidCaseArg struct {
+	Ctx context.Context
+	// Client connects to the mev endpoint of the validator or sentry
+	Client *ethclient.Client
+	// FullNode connects to a full node for chain states, e.g. nonce, block and receipt
+	FullNode *ethclient.Client
+	// Root funds the txs of the bids and Bob receives them
+	Root, Bob Signer
+	Abc       *abc.Abc
+	Builder   *Account
+	// CompetingBuilders are the signers of the builders bidding against each other in the
+	// competition cases, each builder transfers from its own account
+	CompetingBuilders []Signer
+	// Validators[0] is the validator bids are sent to by Client, if ValidatorSet is nil
+	Validators []common.Address
+	// ValidatorSet routes each bid to the mev endpoint of the validator in turn for its block
+	ValidatorSet *ValidatorSet
+	// Nonces allocates the nonces of the accounts for all the cases of a run,
+	// nonces are read from FullNode each time txs are generated if nil
+	Nonces *Nonces
+	// CaseTimeout is the deadline of each case, no deadline if 0
+	CaseTimeout time.Duration
+	// MaxRetries is the max bids a case resends, unlimited if 0
+	MaxRetries int
+	// Confirmations is the number of blocks waited after the block of a bid before checking its txs
+	Confirmations uint64
+	// Logs watches the log of the validator, log expectations of the cases are skipped if nil
+	Logs *LogWatcher
+	// LogWindow is the time waited for a message logged by the validator, DefaultLogWindow if 0
+	LogWindow time.Duration
+	// Recorder records every bid sent with its response, not recorded if nil
+	Recorder *BidRecorder
+	// Metrics collects the cases, bids and receipt waits of the run, not collected if nil
+	Metrics *Metrics
+	// Pool hands out the senders of the txs of each case, root sends them if nil
+	Pool *AccountPool
+	// SendersPerCase is the number of accounts a case takes from Pool, 1 if 0
+	SendersPerCase int
+
+	result *CaseResult
+	// senders are the accounts taken from Pool by the case
+	senders []*Account
+}
+
+type BidCaseFn func(arg *BidCaseArg) error
+
+// retryInterval is the interval between retries of rpc calls.
+const retryInterval = 500 * time.Millisecond
+
+// sleepCtx sleeps for d, it returns the error of ctx if ctx is done before.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// sendBid sends the bid to the validator and records it to the case result, and to Recorder
+// and Metrics with the response.
+func (arg *BidCaseArg) sendBid(bidArgs *types.BidArgs) (common.Hash, error) {
+	arg.recordBid(bidArgs)
+	number := uint64(0)
+	if bidArgs.RawBid != nil {
+		number = bidArgs.RawBid.BlockNumber
+	}
+
+	var record *BidRecord
+	if arg.Recorder != nil {
+		record = arg.newBidRecord(bidArgs, number)
+	}
+
+	start := time.Now()
+	hash, err := arg.mevClient(number).SendBid(arg.Ctx, *bidArgs)
+	latency := time.Since(start)
+
+	arg.Metrics.observeBid(arg.caseName(), latency, err)
+	if record != nil {
+		if er := arg.Recorder.Record(record.respond(hash, err, latency)); er != nil {
+			log.Errorw("failed to record bid", "err", er)
+		}
+	}
+
+	if err == nil {
+		arg.sentBid(bidArgs)
+	} else if IsNonceError(err) {
+		arg.resyncRejected(bidArgs, err)
+	}
+
+	return hash, err
+}
+
+// sentBid records the block of the bid accepted to the nonce managers of the senders of
+// its txs, the nonces of them are in flight until the block is sealed.
+func (arg *BidCaseArg) sentBid(bidArgs *types.BidArgs) {
+	if arg.Nonces == nil || bidArgs.RawBid == nil {
+		return
+	}
+
+	txs, err := decodeBidTxs(bidArgs)
 	if err != nil {
-		log.Errorw("failed to parse private key", "err", err)
+		return
 	}
 
-	publicKey, ok := privateECDSAKey.Public().(*ecdsa.PublicKey)
-	if !ok {
-		log.Errorw("failed to get public key", "err", err)
+	for _, from := range txSenders(txs) {
+		arg.Nonces.Manager(from).Sent(bidArgs.RawBid.BlockNumber)
 	}
+}
 
-	selfAddress := crypto.PubkeyToAddress(*publicKey)
+// resyncRejected resyncs the nonces of the sender of the tx rejected by a nonce error,
+// the nonces of the other accounts are still valid.
+func (arg *BidCaseArg) resyncRejected(bidArgs *types.BidArgs, err error) {
+	if bidArgs.RawBid == nil {
+		return
+	}
+
+	txs, er := decodeBidTxs(bidArgs)
+	if er != nil {
+		log.Errorw("failed to decode bid txs", "err", er)
+		return
+	}
 
-	return privateECDSAKey, selfAddress
+	for _, from := range rejectedSenders(txs, err) {
+		if er = arg.nonces().Manager(from).Resync(arg.Ctx); er != nil {
+			log.Errorw("failed to resync nonces", "address", from, "err", er)
+		}
+	}
 }
 
-type BidCaseArg struct {
-	Ctx           context.Context
-	Client        *ethclient.Client
-	RootPk, BobPk string
-	Abc           *abc.Abc
-	Builder       *Account
-	Validators    []common.Address
+// canRetry reports whether the case can resend a bid, i.e. neither its deadline nor
+// its retry budget is exhausted.
+func (arg *BidCaseArg) canRetry() bool {
+	if arg.Ctx.Err() != nil {
+		return false
+	}
+
+	if arg.MaxRetries > 0 && arg.result != nil && arg.result.Retries >= arg.MaxRetries {
+		log.Infow("retry budget exhausted", "retries", arg.result.Retries)
+		return false
+	}
+
+	return true
 }
 
-type BidCaseFn func(arg *BidCaseArg) error
+// chainID reads the chain id from FullNode, retrying until the deadline of the case.
+func (arg *BidCaseArg) chainID() *big.Int {
+	chainID, err := arg.FullNode.ChainID(arg.Ctx)
+	for err != nil {
+		if er := sleepCtx(arg.Ctx, retryInterval); er != nil {
+			log.Panicw("Client.ChainID", "err", err)
+		}
+		chainID, err = arg.FullNode.ChainID(arg.Ctx)
+	}
+
+	return chainID
+}
+
+func (arg *BidCaseArg) waiter() *Waiter {
+	w := NewWaiter(arg.FullNode, arg.Confirmations)
+	w.metrics, w.caseName = arg.Metrics, arg.caseName()
+	return w
+}
+
+func (arg *BidCaseArg) nonces() *Nonces {
+	if arg.Nonces == nil {
+		return NewNonces(arg.FullNode)
+	}
+
+	return arg.Nonces
+}
+
+// payBidTx creates the PayBidTx of the builder to the validator of the block number, competing
+// bids share the nonce as only one of them is included.
+func (arg *BidCaseArg) payBidTx(number uint64, chainID *big.Int, builderFee *big.Int) ([]byte, error) {
+	nonce, err := arg.nonces().Manager(arg.Builder.Address).Current(arg.Ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get builder nonce, %v", err)
+	}
+
+	return arg.Builder.PayBidTx(nonce, arg.validatorAt(number), chainID, builderFee)
+}
 
 func callOpts() *bind.CallOpts {
 	callOpts := new(bind.CallOpts)
@@ -0,0 +1,212 @@
+package cases
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// BidAccounting is the gas used and gas fee of the txs of a bid, simulated on the latest block.
+type BidAccounting struct {
+	Txs types.Transactions
+	// TxGasUsed is the gas used by each tx
+	TxGasUsed []uint64
+	// BaseFee is the base fee of the latest block, nil before london
+	BaseFee *big.Int
+}
+
+// bidTracerConfig traces the gas used of a call by the callTracer, and the state it changes by the
+// prestateTracer in diff mode.
+var bidTracerConfig = map[string]interface{}{
+	"callTracer":     map[string]interface{}{},
+	"prestateTracer": map[string]interface{}{"diffMode": true},
+}
+
+// overrideAccount is the state override of an account in debug_traceCall.
+type overrideAccount struct {
+	Nonce     *hexutil.Uint64             `json:"nonce,omitempty"`
+	Code      *hexutil.Bytes              `json:"code,omitempty"`
+	Balance   *hexutil.Big                `json:"balance,omitempty"`
+	StateDiff map[common.Hash]common.Hash `json:"stateDiff,omitempty"`
+}
+
+// tracedAccount is an account of the post state of the prestateTracer in diff mode, only the
+// fields changed are set.
+type tracedAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   *uint64                     `json:"nonce"`
+	Code    *hexutil.Bytes              `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+type bidTrace struct {
+	CallTracer struct {
+		GasUsed hexutil.Uint64 `json:"gasUsed"`
+	} `json:"callTracer"`
+	PrestateTracer struct {
+		Post map[common.Address]*tracedAccount `json:"post"`
+	} `json:"prestateTracer"`
+}
+
+// SimulateBid returns the gas used by each tx, simulated in order by debug_traceCall on the latest
+// block. Each tx is traced on the state changed by the txs before it, passed as state overrides, so
+// the gas used is the one of the tx in the bid, refunds and reverts included.
+func SimulateBid(ctx context.Context, client *ethclient.Client, txs types.Transactions) (*BidAccounting, error) {
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &BidAccounting{
+		Txs:       txs,
+		TxGasUsed: make([]uint64, 0, len(txs)),
+		BaseFee:   header.BaseFee,
+	}
+
+	overrides := make(map[common.Address]*overrideAccount)
+	for i, tx := range txs {
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			return nil, fmt.Errorf("tx at index %v, %v", i, err)
+		}
+
+		config := map[string]interface{}{
+			"tracer":         "muxTracer",
+			"tracerConfig":   bidTracerConfig,
+			"stateOverrides": overrides,
+		}
+
+		var trace bidTrace
+		err = client.Client().CallContext(ctx, &trace, "debug_traceCall", traceCallArgs(tx, from),
+			hexutil.EncodeBig(header.Number), config)
+		if err != nil {
+			return nil, fmt.Errorf("tx at index %v, %v", i, err)
+		}
+
+		acc.TxGasUsed = append(acc.TxGasUsed, uint64(trace.CallTracer.GasUsed))
+		for addr, post := range trace.PrestateTracer.Post {
+			overrides[addr] = applyTrace(overrides[addr], post)
+		}
+	}
+
+	return acc, nil
+}
+
+// traceCallArgs returns the args of debug_traceCall of the tx.
+func traceCallArgs(tx *types.Transaction, from common.Address) map[string]interface{} {
+	args := map[string]interface{}{
+		"from":  from,
+		"gas":   hexutil.Uint64(tx.Gas()),
+		"value": (*hexutil.Big)(tx.Value()),
+		"input": hexutil.Bytes(tx.Data()),
+	}
+
+	if tx.To() != nil {
+		args["to"] = tx.To()
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		args["maxFeePerGas"] = (*hexutil.Big)(tx.GasFeeCap())
+		args["maxPriorityFeePerGas"] = (*hexutil.Big)(tx.GasTipCap())
+	} else {
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice())
+	}
+	if tx.Type() != types.LegacyTxType {
+		args["accessList"] = callAccessList(tx.AccessList())
+	}
+
+	return args
+}
+
+// applyTrace returns the override of an account updated by its post state traced.
+func applyTrace(override *overrideAccount, post *tracedAccount) *overrideAccount {
+	if override == nil {
+		override = new(overrideAccount)
+	}
+
+	if post.Balance != nil {
+		override.Balance = post.Balance
+	}
+	if post.Nonce != nil {
+		nonce := hexutil.Uint64(*post.Nonce)
+		override.Nonce = &nonce
+	}
+	if post.Code != nil {
+		override.Code = post.Code
+	}
+	if len(post.Storage) > 0 && override.StateDiff == nil {
+		override.StateDiff = make(map[common.Hash]common.Hash, len(post.Storage))
+	}
+	for key, value := range post.Storage {
+		override.StateDiff[key] = value
+	}
+
+	return override
+}
+
+// GasUsed returns the gas used by all the txs.
+func (a *BidAccounting) GasUsed() uint64 {
+	gasUsed := uint64(0)
+	for _, gas := range a.TxGasUsed {
+		gasUsed += gas
+	}
+
+	return gasUsed
+}
+
+// GasFee returns the gas fee paid to the validator, by the effective gas price of each tx.
+func (a *BidAccounting) GasFee() *big.Int {
+	return a.gasFee(a.BaseFee)
+}
+
+func (a *BidAccounting) gasFee(baseFee *big.Int) *big.Int {
+	gasFee := big.NewInt(0)
+	for i, tx := range a.Txs {
+		fee := new(big.Int).SetUint64(a.TxGasUsed[i])
+		gasFee.Add(gasFee, fee.Mul(fee, effectiveGasPrice(tx, baseFee)))
+	}
+
+	return gasFee
+}
+
+// simulateBid simulates the txs on FullNode.
+func simulateBid(arg *BidCaseArg, txs types.Transactions) (*BidAccounting, error) {
+	acc, err := SimulateBid(arg.Ctx, arg.FullNode, txs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate bid, %v", err)
+	}
+
+	return acc, nil
+}
+
+// callAccessList copies the access list, storageKeys is required by a call even if empty.
+func callAccessList(accessList types.AccessList) types.AccessList {
+	if accessList == nil {
+		return nil
+	}
+
+	list := make(types.AccessList, 0, len(accessList))
+	for _, tuple := range accessList {
+		keys := tuple.StorageKeys
+		if keys == nil {
+			keys = []common.Hash{}
+		}
+		list = append(list, types.AccessTuple{Address: tuple.Address, StorageKeys: keys})
+	}
+
+	return list
+}
+
+// effectiveGasPrice returns the gas price paid by the tx, i.e. min(tip+baseFee, feeCap).
+func effectiveGasPrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return new(big.Int).Set(tx.GasPrice())
+	}
+
+	tip := tx.EffectiveGasTipValue(baseFee)
+	return tip.Add(tip, baseFee)
+}
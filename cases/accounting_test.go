@@ -0,0 +1,58 @@
+package cases
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/bsc-mev-cases/mevtest"
+)
+
+func TestSimulateBid(t *testing.T) {
+	arg, _ := newTestArg(t, 0)
+	bob := arg.Bob.Address()
+
+	txs := GenerateBNBTxsWithOptions(arg, TransferAmountPerTx, 3, MixedTxOptions(bob)...)
+	acc, err := SimulateBid(arg.Ctx, arg.FullNode, txs)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []uint64{21000, 23400, 21000}, acc.TxGasUsed)
+	assert.Equal(t, uint64(65400), acc.GasUsed())
+	assert.Equal(t, new(big.Int).Mul(big.NewInt(65400), DefaultBNBGasPrice), acc.GasFee())
+
+	// by the fee cap of the dynamic fee tx
+	feeCap := new(big.Int).Mul(big.NewInt(44400), DefaultBNBGasPrice)
+	feeCap.Add(feeCap, new(big.Int).Mul(big.NewInt(21000), HighGasPrice))
+	assert.Equal(t, feeCap, acc.gasFee(nil))
+
+	// contract calls by the gas used traced
+	abcTransfer := types.NewTx(&types.LegacyTx{To: &bob, Gas: DefaultGasLimit, GasPrice: DefaultABCGasPrice,
+		Data: common.FromHex("0xa9059cbb")})
+	signed, err := arg.Root.SignTx(abcTransfer, arg.chainID())
+	assert.Nil(t, err)
+	acc, err = SimulateBid(arg.Ctx, arg.FullNode, types.Transactions{signed})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{mevtest.IntrinsicGas(signed)}, acc.TxGasUsed)
+
+	// the second tx is paid by the value of the first, only on the state the first changed
+	key, _ := crypto.GenerateKey()
+	fresh := NewKeySigner(key)
+	freshAddress := fresh.Address()
+	funding, err := arg.Root.SignTx(types.NewTx(&types.LegacyTx{To: &freshAddress, Gas: 21000,
+		GasPrice: DefaultBNBGasPrice, Value: big.NewInt(1e18)}), arg.chainID())
+	assert.Nil(t, err)
+	spending, err := fresh.SignTx(types.NewTx(&types.LegacyTx{To: &bob, Gas: 21000,
+		GasPrice: DefaultBNBGasPrice, Value: big.NewInt(1e17)}), arg.chainID())
+	assert.Nil(t, err)
+
+	acc, err = SimulateBid(arg.Ctx, arg.FullNode, types.Transactions{funding, spending})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{21000, 21000}, acc.TxGasUsed)
+
+	_, err = SimulateBid(arg.Ctx, arg.FullNode, types.Transactions{spending})
+	assert.ErrorContains(t, err, "insufficient funds")
+}
@@ -0,0 +1,200 @@
+package cases
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/multierr"
+)
+
+// SystemRewardContract receives a part of the gas fee of every block on BSC.
+var SystemRewardContract = common.HexToAddress(systemcontracts.SystemRewardContract)
+
+// BalanceDiffs returns the balance changes of the addresses made by the block of the number,
+// i.e. the balances at the block minus the balances at its parent.
+func BalanceDiffs(ctx context.Context, client *ethclient.Client, number uint64, addresses ...common.Address) (
+	map[common.Address]*big.Int, error) {
+	if number == 0 {
+		return nil, fmt.Errorf("no parent of block %v", number)
+	}
+
+	diffs := make(map[common.Address]*big.Int, len(addresses))
+	for _, address := range addresses {
+		before, err := client.BalanceAt(ctx, address, new(big.Int).SetUint64(number-1))
+		if err != nil {
+			return nil, fmt.Errorf("balance of %v at block %v, %v", address, number-1, err)
+		}
+
+		after, err := client.BalanceAt(ctx, address, new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, fmt.Errorf("balance of %v at block %v, %v", address, number, err)
+		}
+
+		diffs[address] = after.Sub(after, before)
+	}
+
+	return diffs, nil
+}
+
+// ExpectedBalanceDiffs returns the balance changes of the addresses made by the txs of the block,
+// the sender pays the value and the gas fee, the receiver gets the value and the coinbase gets
+// the gas fee. The value of a failed tx and the value moved by internal calls are not counted.
+func ExpectedBalanceDiffs(block *types.Block, receipts []*types.Receipt, addresses ...common.Address) (
+	map[common.Address]*big.Int, error) {
+	if len(receipts) != len(block.Transactions()) {
+		return nil, fmt.Errorf("%v receipts of %v txs", len(receipts), len(block.Transactions()))
+	}
+
+	diffs := make(map[common.Address]*big.Int, len(addresses))
+	for _, address := range addresses {
+		diffs[address] = big.NewInt(0)
+	}
+
+	add := func(address common.Address, amount *big.Int) {
+		if diff, ok := diffs[address]; ok {
+			diff.Add(diff, amount)
+		}
+	}
+
+	for i, tx := range block.Transactions() {
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			return nil, fmt.Errorf("tx at index %v, %v", i, err)
+		}
+
+		receipt := receipts[i]
+		fee := new(big.Int).SetUint64(receipt.GasUsed)
+		fee.Mul(fee, receipt.EffectiveGasPrice)
+
+		add(from, new(big.Int).Neg(fee))
+		add(block.Coinbase(), fee)
+
+		if receipt.Status == types.ReceiptStatusSuccessful && tx.To() != nil {
+			add(from, new(big.Int).Neg(tx.Value()))
+			add(*tx.To(), tx.Value())
+		}
+	}
+
+	return diffs, nil
+}
+
+// AssertBidBalances checks the balances of the builder, the validator, the system reward contract
+// and the coinbase are changed by the block of the bid exactly as its txs move them, the txs of
+// the bid pay at least the gasFee, and the PayBidTx moves the builderFee between the builder and
+// the validator.
+func AssertBidBalances(ctx context.Context, client *ethclient.Client, bidArgs *types.BidArgs,
+	builder, validator common.Address) error {
+	number := bidArgs.RawBid.BlockNumber
+	block, err := client.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return fmt.Errorf("block %v, %v", number, err)
+	}
+
+	receipts := make([]*types.Receipt, 0, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		receipt, er := client.TransactionReceipt(ctx, tx.Hash())
+		if er != nil {
+			return fmt.Errorf("receipt of tx at index %v in block %v, %v", i, number, er)
+		}
+
+		receipts = append(receipts, receipt)
+	}
+
+	roles := []struct {
+		name    string
+		address common.Address
+	}{
+		{"builder", builder},
+		{"validator", validator},
+		{"system reward contract", SystemRewardContract},
+		{"coinbase", block.Coinbase()},
+	}
+
+	addresses := make([]common.Address, 0, len(roles))
+	for _, role := range roles {
+		addresses = append(addresses, role.address)
+	}
+
+	expected, err := ExpectedBalanceDiffs(block, receipts, addresses...)
+	if err != nil {
+		return err
+	}
+
+	actual, err := BalanceDiffs(ctx, client, number, addresses...)
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for _, role := range roles {
+		if actual[role.address].Cmp(expected[role.address]) != 0 {
+			errs = multierr.Append(errs, fmt.Errorf("balance of %v %v changed by %v, expected %v",
+				role.name, role.address, actual[role.address], expected[role.address]))
+		}
+	}
+
+	return multierr.Append(errs, assertBidFlows(block, receipts, bidArgs, builder, validator))
+}
+
+// assertBidFlows checks the txs of the bid pay the gasFee and the PayBidTx pays the builderFee.
+func assertBidFlows(block *types.Block, receipts []*types.Receipt, bidArgs *types.BidArgs,
+	builder, validator common.Address) error {
+	txs, err := decodeBidTxs(bidArgs)
+	if err != nil {
+		return err
+	}
+
+	index := make(map[common.Hash]int, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		index[tx.Hash()] = i
+	}
+
+	paid := big.NewInt(0)
+	for i, tx := range txs {
+		pos, ok := index[tx.Hash()]
+		if !ok {
+			return fmt.Errorf("tx at index %v not included in block %v", i, block.NumberU64())
+		}
+
+		fee := new(big.Int).SetUint64(receipts[pos].GasUsed)
+		paid.Add(paid, fee.Mul(fee, receipts[pos].EffectiveGasPrice))
+	}
+
+	var errs error
+	if gasFee := bidArgs.RawBid.GasFee; gasFee != nil && paid.Cmp(gasFee) < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("txs of bid paid gas fee %v, less than %v", paid, gasFee))
+	}
+
+	if len(bidArgs.PayBidTx) == 0 {
+		return errs
+	}
+
+	payBidTx := txs[len(txs)-1]
+	from, err := types.Sender(types.LatestSignerForChainID(payBidTx.ChainId()), payBidTx)
+	if err != nil {
+		return multierr.Append(errs, fmt.Errorf("payBidTx, %v", err))
+	}
+
+	parties := map[common.Address]bool{builder: true, validator: true}
+	if payBidTx.To() == nil || from == *payBidTx.To() || !parties[from] || !parties[*payBidTx.To()] {
+		errs = multierr.Append(errs, fmt.Errorf("payBidTx from %v to %v, not between builder %v and validator %v",
+			from, payBidTx.To(), builder, validator))
+	}
+
+	if builderFee := bidArgs.RawBid.BuilderFee; builderFee == nil || payBidTx.Value().Cmp(builderFee) != 0 {
+		errs = multierr.Append(errs, fmt.Errorf("payBidTx pays %v, expected builder fee %v", payBidTx.Value(), builderFee))
+	}
+
+	return errs
+}
+
+// assertBidBalances checks the balance flows of the bid sealed on FullNode.
+func assertBidBalances(arg *BidCaseArg, bidArgs *types.BidArgs) error {
+	validator := arg.validatorAt(bidArgs.RawBid.BlockNumber)
+	return AssertBidBalances(arg.Ctx, arg.FullNode, bidArgs, arg.Builder.Address, validator)
+}
@@ -0,0 +1,73 @@
+package cases
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertBidBalances(t *testing.T) {
+	arg, server := newTestArg(t, 0)
+	ctx := context.Background()
+
+	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 3)
+	acc, err := simulateBid(arg, txs)
+	assert.Nil(t, err)
+	bidArgs, err := generateValidBid(arg, txs, int64(acc.GasUsed()), acc.GasFee(), true, BuilderFee)
+	assert.Nil(t, err)
+	_, err = arg.sendBid(bidArgs)
+	assert.Nil(t, err)
+
+	_, err = server.Chain().Seal()
+	assert.Nil(t, err)
+
+	builder, validator := arg.Builder.Address, server.Validator()
+	assert.Nil(t, AssertBidBalances(ctx, arg.FullNode, bidArgs, builder, validator))
+
+	diffs, err := BalanceDiffs(ctx, arg.FullNode, bidArgs.RawBid.BlockNumber, builder, validator)
+	assert.Nil(t, err)
+	assert.Equal(t, acc.GasFee().Add(acc.GasFee(), BuilderFee), diffs[validator])
+
+	err = AssertBidBalances(ctx, arg.FullNode, bidArgs, builder, common.HexToAddress("0x01"))
+	assert.ErrorContains(t, err, "not between builder")
+
+	rawBid := *bidArgs.RawBid
+	rawBid.BuilderFee = acc.GasFee()
+	err = AssertBidBalances(ctx, arg.FullNode, &types.BidArgs{RawBid: &rawBid, PayBidTx: bidArgs.PayBidTx}, builder, validator)
+	assert.ErrorContains(t, err, "expected builder fee")
+
+	rawBid.GasFee = acc.GasFee().Add(acc.GasFee(), BuilderFee)
+	err = AssertBidBalances(ctx, arg.FullNode, &types.BidArgs{RawBid: &rawBid, PayBidTx: bidArgs.PayBidTx}, builder, validator)
+	assert.ErrorContains(t, err, "less than")
+}
+
+func TestExpectedBalanceDiffs(t *testing.T) {
+	arg, server := newTestArg(t, 0)
+	ctx := context.Background()
+
+	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 2)
+	acc, err := simulateBid(arg, txs)
+	assert.Nil(t, err)
+	bidArgs, err := generateValidBid(arg, txs, int64(acc.GasUsed()), acc.GasFee(), false, nil)
+	assert.Nil(t, err)
+	_, err = arg.sendBid(bidArgs)
+	assert.Nil(t, err)
+
+	block, err := server.Chain().Seal()
+	assert.Nil(t, err)
+
+	receipts, err := NewWaiter(arg.FullNode, 0).WaitForBid(ctx, bidArgs)
+	assert.Nil(t, err)
+
+	validator := server.Validator()
+	diffs, err := ExpectedBalanceDiffs(block, receipts, validator, SystemRewardContract)
+	assert.Nil(t, err)
+	assert.Equal(t, acc.GasFee(), diffs[validator])
+	assert.Zero(t, diffs[SystemRewardContract].Sign())
+
+	_, err = ExpectedBalanceDiffs(block, receipts[:1], validator)
+	assert.ErrorContains(t, err, "1 receipts of 2 txs")
+}
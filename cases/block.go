@@ -0,0 +1,76 @@
+package cases
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// AssertBidInBlock checks the block is sealed with the bid, i.e. the block is of the number
+// and parent of the bid, and contains the txs of the bid contiguously in the submitted order,
+// followed by its PayBidTx if any.
+func AssertBidInBlock(block *types.Block, bidArgs *types.BidArgs) error {
+	rawBid := bidArgs.RawBid
+	if block.NumberU64() != rawBid.BlockNumber {
+		return fmt.Errorf("block number %v, expected %v", block.NumberU64(), rawBid.BlockNumber)
+	}
+
+	if block.ParentHash() != rawBid.ParentHash {
+		return fmt.Errorf("block %v is on parent %v, bid on %v", block.NumberU64(), block.ParentHash(), rawBid.ParentHash)
+	}
+
+	txs, err := decodeBidTxs(bidArgs)
+	if err != nil {
+		return err
+	}
+
+	if len(txs) == 0 {
+		return nil
+	}
+
+	blockTxs := block.Transactions()
+	start := -1
+	for i, tx := range blockTxs {
+		if tx.Hash() == txs[0].Hash() {
+			start = i
+			break
+		}
+	}
+
+	if start < 0 {
+		return fmt.Errorf("tx at index 0 not included in block %v", block.NumberU64())
+	}
+
+	for i, tx := range txs {
+		pos := start + i
+		if pos >= len(blockTxs) || blockTxs[pos].Hash() != tx.Hash() {
+			if i == len(txs)-1 && len(bidArgs.PayBidTx) != 0 {
+				return fmt.Errorf("payBidTx not placed after the txs of bid in block %v", block.NumberU64())
+			}
+
+			return fmt.Errorf("tx at index %v not placed at %v in block %v", i, pos, block.NumberU64())
+		}
+	}
+
+	return nil
+}
+
+// decodeBidTxs decodes the txs of the bid, followed by its PayBidTx if any.
+func decodeBidTxs(bidArgs *types.BidArgs) (types.Transactions, error) {
+	encoded := bidArgs.RawBid.Txs
+	if len(bidArgs.PayBidTx) != 0 {
+		encoded = append(encoded[:len(encoded):len(encoded)], bidArgs.PayBidTx)
+	}
+
+	txs := make(types.Transactions, 0, len(encoded))
+	for i, b := range encoded {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(b); err != nil {
+			return nil, fmt.Errorf("failed to decode tx at index %v, %v", i, err)
+		}
+
+		txs = append(txs, tx)
+	}
+
+	return txs, nil
+}
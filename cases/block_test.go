@@ -0,0 +1,69 @@
+package cases
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertBidInBlock(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := types.LatestSignerForChainID(big.NewInt(56))
+	to := common.HexToAddress("0x02")
+
+	txs := make(types.Transactions, 0, 4)
+	for i := 0; i < 4; i++ {
+		tx, err := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: uint64(i), To: &to, Gas: 21000, GasPrice: big.NewInt(1)})
+		assert.Nil(t, err)
+		txs = append(txs, tx)
+	}
+
+	encode := func(tx *types.Transaction) hexutil.Bytes {
+		b, err := tx.MarshalBinary()
+		assert.Nil(t, err)
+		return b
+	}
+
+	parent := common.HexToHash("0x01")
+	bidArgs := &types.BidArgs{
+		RawBid: &types.RawBid{
+			BlockNumber: 10,
+			ParentHash:  parent,
+			Txs:         []hexutil.Bytes{encode(txs[1]), encode(txs[2])},
+		},
+		PayBidTx: encode(txs[3]),
+	}
+
+	block := func(number uint64, parentHash common.Hash, txs ...*types.Transaction) *types.Block {
+		header := &types.Header{Number: new(big.Int).SetUint64(number), ParentHash: parentHash}
+		return types.NewBlockWithHeader(header).WithBody(txs, nil)
+	}
+
+	assert.Nil(t, AssertBidInBlock(block(10, parent, txs[0], txs[1], txs[2], txs[3]), bidArgs))
+
+	err := AssertBidInBlock(block(11, parent, txs...), bidArgs)
+	assert.ErrorContains(t, err, "block number 11, expected 10")
+
+	err = AssertBidInBlock(block(10, common.HexToHash("0x02"), txs...), bidArgs)
+	assert.ErrorContains(t, err, "is on parent")
+
+	err = AssertBidInBlock(block(10, parent, txs[0], txs[3]), bidArgs)
+	assert.ErrorContains(t, err, "tx at index 0 not included")
+
+	err = AssertBidInBlock(block(10, parent, txs[1], txs[0], txs[2], txs[3]), bidArgs)
+	assert.ErrorContains(t, err, "tx at index 1 not placed at 1")
+
+	err = AssertBidInBlock(block(10, parent, txs[1], txs[2], txs[0], txs[3]), bidArgs)
+	assert.ErrorContains(t, err, "payBidTx not placed after the txs of bid")
+
+	err = AssertBidInBlock(block(10, parent, txs[1], txs[2]), bidArgs)
+	assert.ErrorContains(t, err, "payBidTx not placed after the txs of bid")
+
+	bidArgs.PayBidTx = nil
+	assert.Nil(t, AssertBidInBlock(block(10, parent, txs[1], txs[2]), bidArgs))
+}
@@ -0,0 +1,187 @@
+package cases
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/bsc-mev-cases/mevtest"
+)
+
+var testBalance = new(big.Int).Mul(big.NewInt(1e18), big.NewInt(1e6))
+
+func newTestKey() (*KeySigner, common.Address) {
+	key, _ := crypto.GenerateKey()
+	signer := NewKeySigner(key)
+	return signer, signer.Address()
+}
+
+// newTestArg creates the case arg against a fake validator sealing a block every period,
+// blocks are only sealed by Chain.Seal if period is 0. The bids of the builder are signed by
+// a remote signer, the same as builders signing by an external service.
+func newTestArg(t testing.TB, period time.Duration) (*BidCaseArg, *mevtest.Server) {
+	rootKey, root := newTestKey()
+	bobKey, _ := newTestKey()
+
+	builderKey, _ := crypto.GenerateKey()
+	builder := crypto.PubkeyToAddress(builderKey.PublicKey)
+	signer := mevtest.NewSignerServer(builderKey)
+	t.Cleanup(signer.Close)
+
+	alloc := map[common.Address]*big.Int{
+		root:    testBalance,
+		builder: testBalance,
+	}
+	builders := []common.Address{builder}
+
+	competingKeys := make([]Signer, 0, 3)
+	for i := 0; i < 3; i++ {
+		key, competing := newTestKey()
+		alloc[competing] = testBalance
+		builders = append(builders, competing)
+		competingKeys = append(competingKeys, key)
+	}
+
+	chain := mevtest.NewChain(mevtest.ChainConfig{Alloc: alloc})
+	server := mevtest.NewServer(chain, mevtest.Config{
+		Validator: common.HexToAddress("0xe0239549edd90eb0e4abf5cbc9edad1a4af20d3e"),
+		Builders:  builders,
+	})
+	t.Cleanup(server.Close)
+
+	if period > 0 {
+		chain.Start(period)
+		t.Cleanup(chain.Stop)
+	}
+
+	ctx := context.Background()
+	client, err := ethclient.Dial(server.URL)
+	assert.Nil(t, err)
+
+	builderSigner, err := DialRemoteSigner(ctx, signer.URL, builder)
+	assert.Nil(t, err)
+	t.Cleanup(builderSigner.Close)
+
+	return &BidCaseArg{
+		Ctx:        ctx,
+		Client:     client,
+		FullNode:   client,
+		Root:       rootKey,
+		Bob:        bobKey,
+		Builder:    NewAccount(ctx, client, builderSigner, nil),
+		Validators: []common.Address{server.Validator()},
+		Nonces:     NewNonces(client),
+
+		CompetingBuilders: competingKeys,
+	}, server
+}
+
+func TestQueryCases(t *testing.T) {
+	arg, _ := newTestArg(t, 0)
+
+	for _, res := range RunQueryCases(arg) {
+		assert.Equal(t, StatusPassed, res.Status, "%s: %s", res.Name, res.Error)
+	}
+}
+
+func TestInvalidCases(t *testing.T) {
+	arg, server := newTestArg(t, 0)
+
+	for _, c := range Cases(TagInvalid) {
+		// waits for the block of the bid, see TestNotSealedCases
+		if c.Expect == ExpectNotSealed {
+			continue
+		}
+
+		// a validator accepts at most 3 bids from a builder for a block
+		_, err := server.Chain().Seal()
+		assert.Nil(t, err)
+
+		res := runCaseFn(arg, c.Name, c.Fn)
+		assert.Equal(t, StatusPassed, res.Status, "%s: %s", res.Name, res.Error)
+		assert.NotEqual(t, common.Hash{}, res.BidHash, c.Name)
+	}
+}
+
+func TestNotSealedCases(t *testing.T) {
+	arg, server := newTestArg(t, 200*time.Millisecond)
+
+	for _, c := range Cases(TagInvalid) {
+		if c.Expect != ExpectNotSealed {
+			continue
+		}
+
+		res := runCase(arg, c)
+		assert.Equal(t, StatusPassed, res.Status, "%s: %s", res.Name, res.Error)
+
+		issues := server.Issues()
+		if assert.NotEmpty(t, issues, c.Name) {
+			assert.Equal(t, res.BidHash, issues[len(issues)-1].BidHash, c.Name)
+			assert.Contains(t, issues[len(issues)-1].Message, "gas used mismatch", c.Name)
+		}
+	}
+}
+
+func TestValidCases(t *testing.T) {
+	if testing.Short() {
+		t.Skip("valid cases wait for receipts")
+	}
+
+	arg, server := newTestArg(t, 500*time.Millisecond)
+
+	// PayBidTx twice, the nonce of the builder moves after the first included
+	for _, name := range []string{"ValidBid_NilPayBidTx_200", "ValidBid_PayBidTx_200", "ValidBid_PayBidTx_200",
+		"ValidBid_MixedTxTypes_30"} {
+		c, err := Lookup(name)
+		assert.Nil(t, err)
+
+		res := runCaseFn(arg, c.Name, c.Fn)
+		assert.Equal(t, StatusPassed, res.Status, "%s: %s", res.Name, res.Error)
+	}
+
+	assert.Empty(t, server.Issues())
+}
+
+func TestCaseTimeout(t *testing.T) {
+	arg, server := newTestArg(t, 0)
+	arg.CaseTimeout = 200 * time.Millisecond
+	server.SetInTurn(false)
+
+	c, err := Lookup("InvalidBid_OldBlockNumber_20")
+	assert.Nil(t, err)
+
+	res := runCase(arg, c)
+	assert.Equal(t, StatusTimeout, res.Status, res.Error)
+	assert.True(t, res.Failed())
+	assert.Contains(t, res.Error, "wait for in turn")
+}
+
+func TestCaseMaxRetries(t *testing.T) {
+	arg, server := newTestArg(t, 0)
+	arg.MaxRetries = 2
+	for i := 0; i < 3; i++ {
+		server.InjectError(mevtest.NewBidError(types.InvalidBidParamError, "injected"))
+	}
+
+	res := runCaseFn(arg, "ValidBid_NilPayBidTx_1", ValidBid_NilPayBidTx_1)
+	assert.Equal(t, StatusFailed, res.Status)
+	assert.Equal(t, 2, res.Retries)
+	assert.Contains(t, res.Error, "injected")
+}
+
+func TestCasePanic(t *testing.T) {
+	arg, _ := newTestArg(t, 0)
+
+	res := runCaseFn(arg, "Panic", func(arg *BidCaseArg) error {
+		panic("boom")
+	})
+	assert.Equal(t, StatusFailed, res.Status)
+	assert.Contains(t, res.Error, "boom")
+}
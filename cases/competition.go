@@ -0,0 +1,189 @@
+package cases
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/multierr"
+
+	"github.com/bnb-chain/bsc-mev-cases/log"
+)
+
+func init() {
+	Register(&CaseInfo{
+		Name:          "Competition_GasPrice_10",
+		Description:   "builders bid 10 BNB transfers for the same block, each at a higher gas price",
+		Tags:          []Tag{TagCompetition},
+		Expect:        ExpectBestBidSealed,
+		EstimatedCost: 0.063,
+		Fn:            Competition_GasPrice_10,
+	})
+	Register(&CaseInfo{
+		Name:          "Competition_TxCount",
+		Description:   "builders bid 10, 20, ... BNB transfers for the same block at the same gas price",
+		Tags:          []Tag{TagCompetition},
+		Expect:        ExpectBestBidSealed,
+		EstimatedCost: 0.063,
+		Fn:            Competition_TxCount,
+	})
+}
+
+// RunCompetitionCases runs the cases tagged competition.
+func RunCompetitionCases(arg *BidCaseArg) []*CaseResult {
+	return RunCases(arg, TagCompetition)
+}
+
+// competitor is a builder of the competition, bidding the txs transferred from its own account.
+type competitor struct {
+	// arg is a copy of the case arg bidding as the builder
+	arg     *BidCaseArg
+	factory *BidFactory
+}
+
+func newCompetitors(arg *BidCaseArg) ([]*competitor, error) {
+	if len(arg.CompetingBuilders) < 2 {
+		return nil, fmt.Errorf("competition needs at least 2 builders, got %v", len(arg.CompetingBuilders))
+	}
+
+	competitors := make([]*competitor, 0, len(arg.CompetingBuilders))
+	for _, signer := range arg.CompetingBuilders {
+		factory := NewBidFactory(arg.Ctx, arg.FullNode, arg.Nonces, signer, arg.Bob, arg.Abc)
+
+		// the bids of a round are recorded once by runCompetition, the result of the builder only
+		// names the case for the metrics
+		builderArg := *arg
+		builderArg.Builder = factory.Root()
+		if arg.result != nil {
+			builderArg.result = &CaseResult{Name: arg.result.Name}
+		}
+		competitors = append(competitors, &competitor{arg: &builderArg, factory: factory})
+	}
+
+	return competitors, nil
+}
+
+// competitionTxsFn generates the txs of the bid of the i-th builder.
+type competitionTxsFn func(i int, factory *BidFactory) (types.Transactions, error)
+
+// Competition_GasPrice_10
+// the i-th builder bids gasFee = 21000 * 10 * 0.0000001 * i BNB, 0.063 BNB of the best of 3 builders
+func Competition_GasPrice_10(arg *BidCaseArg) error {
+	return runCompetition(arg, func(i int, factory *BidFactory) (types.Transactions, error) {
+		gasPrice := new(big.Int).Mul(DefaultBNBGasPrice, big.NewInt(int64(i+1)))
+		return factory.BundleBNBWithOptions(TransferAmountPerTx, 10, TxOptions{GasPrice: gasPrice})
+	})
+}
+
+// Competition_TxCount
+// the i-th builder bids gasFee = 21000 * 10 * i * 0.0000001 BNB, 0.063 BNB of the best of 3 builders
+func Competition_TxCount(arg *BidCaseArg) error {
+	return runCompetition(arg, func(i int, factory *BidFactory) (types.Transactions, error) {
+		return factory.BundleBNB(TransferAmountPerTx, 10*(i+1))
+	})
+}
+
+// runCompetition sends a bid of every builder for the same block, and checks the validator
+// reports and seals the bid of the highest reward.
+func runCompetition(arg *BidCaseArg, txsFn competitionTxsFn) error {
+	competitors, err := newCompetitors(arg)
+	if err != nil {
+		return err
+	}
+
+	chainID := arg.chainID()
+	bids := make([]*types.BidArgs, len(competitors))
+	txs := make([]types.Transactions, len(competitors))
+
+	var sendErr error
+	for arg.canRetry() {
+		block, err := arg.FullNode.BlockByNumber(arg.Ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		for i, c := range competitors {
+			if txs[i], err = txsFn(i, c.factory); err != nil {
+				return err
+			}
+
+			acc, err := simulateBid(c.arg, txs[i])
+			if err != nil {
+				return err
+			}
+			bids[i], err = genValidBidWithBlock(c.arg, txs[i], int64(acc.GasUsed()), acc.GasFee(), false, nil, chainID, block)
+			if err != nil {
+				return err
+			}
+		}
+
+		sendErr = nil
+		for i, c := range competitors {
+			if _, err = c.arg.sendBid(bids[i]); err != nil {
+				sendErr = multierr.Append(sendErr, fmt.Errorf("bid of builder %v, %v", c.arg.Builder.Address, err))
+			}
+		}
+		arg.recordBid(bids[bestBid(bids)])
+
+		if sendErr == nil {
+			return assertBestBidSealed(arg, block, competitors, bids)
+		}
+
+		// the bids accepted may be sealed or dropped, the next bids start from the nonces on chain
+		if err = arg.nonces().Settle(arg.Ctx); err != nil {
+			log.Errorw("failed to settle nonces", "err", err)
+		}
+
+		log.Infow("retry", "reason", sendErr)
+		_ = sleepCtx(arg.Ctx, retryInterval)
+	}
+
+	return fmt.Errorf("bids not accepted, %v", sendErr)
+}
+
+// assertBestBidSealed checks BestBidGasFee reports the gasFee of the bid of the highest reward
+// once the bids are sent, and the bid is sealed in the block.
+func assertBestBidSealed(arg *BidCaseArg, parent *types.Block, competitors []*competitor, bids []*types.BidArgs) error {
+	best := bestBid(bids)
+	builder := competitors[best].arg.Builder.Address
+	expected := bids[best].RawBid.GasFee
+
+	// queried right after the bids are sent, before they are dropped by the block sealed
+	reported, err := arg.mevClient(parent.NumberU64()+1).BestBidGasFee(arg.Ctx, parent.Hash())
+	if err != nil {
+		return fmt.Errorf("BestBidGasFee, %v", err)
+	}
+
+	if reported.Cmp(expected) != 0 {
+		return fmt.Errorf("best bid gas fee %v reported, expected %v of builder %v", reported, expected, builder)
+	}
+
+	receipts, err := arg.waiter().WaitForBid(arg.Ctx, bids[best])
+	if err != nil {
+		return fmt.Errorf("best bid of builder %v not sealed, %v", builder, err)
+	}
+
+	return assertReceiptsSucceed(receipts)
+}
+
+// bestBid returns the index of the bid of the highest reward.
+func bestBid(bids []*types.BidArgs) int {
+	best := 0
+	for i, bid := range bids {
+		if bidReward(bid).Cmp(bidReward(bids[best])) > 0 {
+			best = i
+		}
+	}
+
+	return best
+}
+
+// bidReward returns the reward of the validator claimed by the bid.
+func bidReward(bidArgs *types.BidArgs) *big.Int {
+	reward := new(big.Int).Set(bidArgs.RawBid.GasFee)
+	if bidArgs.RawBid.BuilderFee != nil {
+		reward.Sub(reward, bidArgs.RawBid.BuilderFee)
+	}
+
+	return reward
+}
@@ -0,0 +1,35 @@
+package cases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompetitionCases(t *testing.T) {
+	if testing.Short() {
+		t.Skip("competition cases wait for receipts")
+	}
+
+	arg, server := newTestArg(t, 500*time.Millisecond)
+
+	for _, res := range RunCompetitionCases(arg) {
+		assert.Equal(t, StatusPassed, res.Status, "%s: %s", res.Name, res.Error)
+		// one bid recorded per round of the builders
+		assert.Equal(t, 0, res.Retries, res.Name)
+		assert.NotEqual(t, common.Hash{}, res.BidHash, res.Name)
+	}
+
+	assert.Empty(t, server.Issues())
+}
+
+func TestCompetitionBuilders(t *testing.T) {
+	arg, _ := newTestArg(t, 0)
+	arg.CompetingBuilders = arg.CompetingBuilders[:1]
+
+	res := RunCase(arg, "Competition_TxCount")
+	assert.Equal(t, StatusFailed, res[0].Status)
+	assert.Contains(t, res[0].Error, "at least 2 builders")
+}
@@ -12,44 +12,47 @@ import (
 	"github.com/bnb-chain/bsc-mev-cases/utils/syncutils"
 )
 
-func RunConcurrency(arg *BidCaseArg) {
+func RunConcurrency(arg *BidCaseArg) []*CaseResult {
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
 	counter := 0
+	results := make([]*CaseResult, 0)
 
 	for {
 		select {
 		case <-ticker.C:
-			runConcurrency(arg)
+			res := runCaseFn(arg, "Concurrency", runConcurrency)
+			if res.Failed() {
+				println("concurrency failed ", res.Error)
+			}
+			results = append(results, res)
 			counter++
 			if counter > 120 {
 				fmt.Println("concurrency success")
-				return
+				return results
 			}
 		}
 	}
 }
 
-func runConcurrency(arg *BidCaseArg) {
+func runConcurrency(arg *BidCaseArg) error {
 	txCounts := []int{8, 32, 512}
 	bidArgs := make([]*types.BidArgs, len(txCounts))
 	txs := make([]types.Transactions, len(txCounts))
 
-	chainID, err := arg.Client.ChainID(arg.Ctx)
-	for err != nil {
-		chainID, err = arg.Client.ChainID(arg.Ctx)
-	}
+	chainID := arg.chainID()
 	println("chainID ", chainID.String())
 
 	retry := true
+	var sendErr error
 
-	for retry {
-		blockNumber, err := fullNode.BlockNumber(arg.Ctx)
+	for retry && arg.canRetry() {
+		blockNumber, err := arg.FullNode.BlockNumber(arg.Ctx)
 		if err != nil {
 			log.Panicw("Client.BlockNumber", "err", err)
 		}
 
-		block, err := fullNode.BlockByNumber(arg.Ctx, big.NewInt(int64(blockNumber)))
+		block, err := arg.FullNode.BlockByNumber(arg.Ctx, big.NewInt(int64(blockNumber)))
 		if err != nil {
 			log.Panicw("Client.BlockByNumber", "err", err)
 		}
@@ -57,14 +60,18 @@ func runConcurrency(arg *BidCaseArg) {
 		println("blockNumber ", blockNumber, " blockHash ", block.Hash().String())
 
 		for i, c := range txCounts {
-			bidArgs[i], txs[i] = geBidArgs(arg, c, chainID, block)
+			if bidArgs[i], txs[i], err = geBidArgs(arg, c, chainID, block); err != nil {
+				return err
+			}
+			// the bids compete for the same block, so their txs start from the same nonce
+			releaseNonces(arg, txs[i])
 		}
 
 		br := syncutils.NewBatchRunner().WithConcurrencyLimit(10)
 		br.AddTasks(func() error {
 			for _, b := range bidArgs {
 				var er error
-				_, er = arg.Client.SendBid(arg.Ctx, *b)
+				_, er = arg.sendBid(b)
 				if er != nil {
 					return er
 				}
@@ -72,44 +79,41 @@ func runConcurrency(arg *BidCaseArg) {
 
 			return nil
 		})
-		err = br.Exec()
+		sendErr = br.Exec()
 
-		if err != nil {
-			println(err.Error())
-			time.Sleep(500 * time.Millisecond)
+		if sendErr != nil {
+			println(sendErr.Error())
+			_ = sleepCtx(arg.Ctx, retryInterval)
 		} else {
 			retry = false
 		}
 	}
 
-	time.Sleep(5 * time.Second)
-
-	for _, tx := range txs[2] {
-		receipt, err := arg.Client.TransactionReceipt(arg.Ctx, tx.Hash())
-		if err != nil {
-			println("concurrency failed ", err.Error())
-			return
-		}
+	if retry {
+		return fmt.Errorf("bids not accepted, %v", sendErr)
+	}
 
-		if receipt.Status != 1 {
-			println("concurrency failed ", receipt.Status)
-		}
+	receipts, err := arg.waiter().WaitForBid(arg.Ctx, bidArgs[2])
+	if err != nil {
+		return err
 	}
+
+	return assertReceiptsSucceed(receipts)
 }
 
 func geBidArgs(arg *BidCaseArg, txCount int, chainID *big.Int, block *types.Block) (
-	*types.BidArgs, types.Transactions) {
+	*types.BidArgs, types.Transactions, error) {
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, txCount)
 	gasUsed := BNBGasUsed * int64(txCount)
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := geValidBidWithBlock(arg, txs, gasUsed, gasFee, false, nil, chainID, block)
+	bidArgs, err := genValidBidWithBlock(arg, txs, gasUsed, gasFee, false, nil, chainID, block)
 
-	return bidArgs, txs
+	return bidArgs, txs, err
 }
 
-func geValidBidWithBlock(
+func genValidBidWithBlock(
 	arg *BidCaseArg, txs []*types.Transaction, gasUsed int64, gasFee *big.Int, payBuilder bool, builderFee *big.Int,
-	chainID *big.Int, block *types.Block) *types.BidArgs {
+	chainID *big.Int, block *types.Block) (*types.BidArgs, error) {
 	txBytes := make([]hexutil.Bytes, 0)
 	for _, tx := range txs {
 		txByte, err := tx.MarshalBinary()
@@ -131,14 +135,14 @@ func geValidBidWithBlock(
 		rawBid.BuilderFee = builderFee
 	}
 
-	bidArgs := arg.Builder.SignBid(rawBid)
-	if !payBuilder {
-		return bidArgs
+	bidArgs, err := arg.Builder.SignBid(rawBid)
+	if err != nil || !payBuilder {
+		return bidArgs, err
 	}
 
-	validator := arg.Validators[0]
-	payBidTx := arg.Builder.PayBidTx(validator, chainID, builderFee)
-	bidArgs.PayBidTx = payBidTx
+	if bidArgs.PayBidTx, err = arg.payBidTx(rawBid.BlockNumber, chainID, builderFee); err != nil {
+		return nil, err
+	}
 	bidArgs.PayBidTxGasUsed = uint64(PayBidGasUsed)
-	return bidArgs
+	return bidArgs, nil
 }
@@ -2,8 +2,11 @@ package cases
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -12,9 +15,12 @@ import (
 	"github.com/bnb-chain/bsc-mev-cases/log"
 )
 
+// BNBGasUsed and ABCGasUsed are the gas used by a transfer, ABCGasUsed of a receiver holding ABC
+// already, use SimulateBid for the gas used of txs.
 var (
-	BNBGasUsed          = int64(21000)
-	ABCGasUsed          = int64(21620)
+	BNBGasUsed = int64(21000)
+	ABCGasUsed = int64(21620)
+	// PayBidGasUsed is the gas reserved for PayBidTx, the max accepted by the validator
 	PayBidGasUsed       = int64(25000)
 	BuilderFee          = big.NewInt(1e14 * 5)
 	TransferAmountPerTx = big.NewInt(1e16)
@@ -28,31 +34,44 @@ type BidFactory struct {
 
 	chainID *big.Int
 	client  *ethclient.Client
+	nonces  *Nonces
+	// senders send the txs in turn instead of root if set, e.g. accounts of an AccountPool
+	senders []*Account
 }
 
 func NewBidFactory(
 	ctx context.Context,
 	client *ethclient.Client,
-	rootPk, bobPk string,
+	nonces *Nonces,
+	root, bob Signer,
 	abcSol *abc.Abc,
 ) *BidFactory {
-	chainID, err := fullNode.ChainID(ctx)
+	chainID, err := client.ChainID(ctx)
 	if err != nil {
 		log.Errorw("Client.ChainID", "err", err)
 	}
 
-	root := NewAccount(rootPk, abcSol)
-	bob := NewAccount(bobPk, abcSol)
+	if nonces == nil {
+		nonces = NewNonces(client)
+	}
 
 	return &BidFactory{
 		ctx:     ctx,
-		root:    root,
-		bob:     bob,
+		root:    newAccount(root, abcSol),
+		bob:     newAccount(bob, abcSol),
 		chainID: chainID,
 		client:  client,
+		nonces:  nonces,
 	}
 }
 
+// WithSenders makes the txs of the bundles sent by the senders in turn instead of root, the
+// senders need BNB and ABC, e.g. accounts funded by FundAccounts.
+func (b *BidFactory) WithSenders(senders []*Account) *BidFactory {
+	b.senders = senders
+	return b
+}
+
 func (b *BidFactory) Accounts() []*Account {
 	return []*Account{b.root, b.bob}
 }
@@ -66,80 +85,115 @@ func (b *BidFactory) Bob() *Account {
 }
 
 func (b *BidFactory) BundleBNB(amount *big.Int, bundleSize int) ([]*types.Transaction, error) {
-	from := b.root
-	to := b.bob
-
-	txs := make([]*types.Transaction, 0)
-	for i := 0; i < bundleSize; i++ {
-		tx, err := from.TransferBNB(from.Nonce, to.Address, b.chainID, amount)
+	return b.bundle(bundleSize, func(from *Account, nonce uint64, _ int) (*types.Transaction, error) {
+		tx, err := from.TransferBNB(nonce, b.bob.Address, b.chainID, amount)
 		if err != nil {
 			log.Errorw("failed to create BNB transfer tx", "err", err)
-			return nil, err
 		}
-
-		txs = append(txs, tx)
-		from.Nonce++
-	}
-
-	return txs, nil
+		return tx, err
+	})
 }
 
 func (b *BidFactory) BundleBNBWithHighGas(amount *big.Int, bundleSize int) ([]*types.Transaction, error) {
-	from := b.root
-	to := b.bob
-
-	txs := make([]*types.Transaction, 0)
-	for i := 0; i < bundleSize; i++ {
-		tx, err := from.TransferBNBWithHighGas(from.Nonce, to.Address, b.chainID, amount)
+	return b.bundle(bundleSize, func(from *Account, nonce uint64, _ int) (*types.Transaction, error) {
+		tx, err := from.TransferBNBWithHighGas(nonce, b.bob.Address, b.chainID, amount)
 		if err != nil {
 			log.Errorw("failed to create BNB transfer tx", "err", err)
-			return nil, err
 		}
+		return tx, err
+	})
+}
 
-		txs = append(txs, tx)
-		from.Nonce++
+// BundleBNBWithOptions creates BNB transfers of root to bob, the i-th tx is created
+// with options[i%len(options)].
+func (b *BidFactory) BundleBNBWithOptions(amount *big.Int, bundleSize int, options ...TxOptions) ([]*types.Transaction, error) {
+	if len(options) == 0 {
+		return nil, errors.New("no tx options")
 	}
 
-	return txs, nil
+	return b.bundle(bundleSize, func(from *Account, nonce uint64, i int) (*types.Transaction, error) {
+		tx, err := from.TransferBNBWithOptions(nonce, b.bob.Address, b.chainID, amount, options[i%len(options)])
+		if err != nil {
+			log.Errorw("failed to create BNB transfer tx", "err", err)
+		}
+		return tx, err
+	})
 }
 
 func (b *BidFactory) BundleBNBNoSign(from, to *Account, amount *big.Int, bundleSize int) ([]*types.Transaction, error) {
+	nonce, err := b.nonces.Manager(from.Address).Allocate(b.ctx, bundleSize)
+	if err != nil {
+		log.Errorw("failed to allocate nonces", "err", err)
+		return nil, err
+	}
+
 	txs := make([]*types.Transaction, 0)
 	for i := 0; i < bundleSize; i++ {
-		tx, err := from.TransferBNBNoSign(from.Nonce, to.Address, b.chainID, amount)
+		tx, err := from.TransferBNBNoSign(nonce+uint64(i), to.Address, b.chainID, amount)
 		if err != nil {
 			log.Errorw("failed to create BNB transfer tx", "err", err)
 			return nil, err
 		}
 
 		txs = append(txs, tx)
-		from.Nonce++
 	}
 
 	return txs, nil
 }
 
 func (b *BidFactory) BundleABC(amount *big.Int, bundleSize int) (types.Transactions, error) {
-	from := b.root
-	to := b.bob
+	return b.bundle(bundleSize, func(from *Account, nonce uint64, _ int) (*types.Transaction, error) {
+		tx, err := from.TransferABC(nonce, b.bob.Address, b.chainID, amount)
+		if err != nil {
+			log.Errorw("failed to create ABC transfer tx", "err", err)
+		}
+		return tx, err
+	})
+}
 
-	txs := make([]*types.Transaction, 0)
+// bundle creates bundleSize txs by create, sent by root or by the senders in turn if set.
+// The nonces of each sender are allocated contiguously.
+func (b *BidFactory) bundle(bundleSize int, create func(from *Account, nonce uint64, i int) (*types.Transaction, error)) (
+	[]*types.Transaction, error) {
+	senders := b.senders
+	if len(senders) == 0 {
+		senders = []*Account{b.root}
+	}
+	if len(senders) > bundleSize {
+		senders = senders[:bundleSize]
+	}
+
+	nonces := make([]uint64, len(senders))
+	for i, from := range senders {
+		count := bundleSize / len(senders)
+		if i < bundleSize%len(senders) {
+			count++
+		}
+
+		nonce, err := b.nonces.Manager(from.Address).Allocate(b.ctx, count)
+		if err != nil {
+			log.Errorw("failed to allocate nonces", "err", err)
+			return nil, err
+		}
+		nonces[i] = nonce
+	}
+
+	txs := make([]*types.Transaction, 0, bundleSize)
 	for i := 0; i < bundleSize; i++ {
-		tx, err := from.TransferABC(from.Nonce, to.Address, b.chainID, amount)
+		s := i % len(senders)
+		tx, err := create(senders[s], nonces[s]+uint64(i/len(senders)), i)
 		if err != nil {
-			log.Errorw("failed to create ABC transfer tx", "err", err)
 			return nil, err
 		}
 
 		txs = append(txs, tx)
-		from.Nonce++
 	}
 
 	return txs, nil
 }
 
 func GenerateBNBTxs(arg *BidCaseArg, amountPerTx *big.Int, txcount int) types.Transactions {
-	bundleFactory := NewBidFactory(arg.Ctx, arg.Client, arg.RootPk, arg.BobPk, arg.Abc)
+	bundleFactory := arg.bidFactory()
 
 	txs := make([]*types.Transaction, 0)
 
@@ -152,8 +206,44 @@ func GenerateBNBTxs(arg *BidCaseArg, amountPerTx *big.Int, txcount int) types.Tr
 	return txs
 }
 
+// GenerateBNBTxsWithOptions generates BNB transfers of the tx types in options, in turn.
+func GenerateBNBTxsWithOptions(arg *BidCaseArg, amountPerTx *big.Int, txcount int, options ...TxOptions) types.Transactions {
+	bundleFactory := arg.bidFactory()
+
+	txs := make([]*types.Transaction, 0)
+
+	bundle, err := bundleFactory.BundleBNBWithOptions(amountPerTx, txcount, options...)
+	if err != nil {
+		log.Errorw("bundleFactory.BundleBNBWithOptions", "err", err)
+	}
+	txs = append(txs, bundle...)
+
+	return txs
+}
+
+// MixedTxOptions returns the options of a legacy, an access list and a dynamic fee tx,
+// the dynamic fee tx pays the same tip as the others under a higher fee cap.
+func MixedTxOptions(accessed common.Address) []TxOptions {
+	return []TxOptions{
+		{
+			Type:     types.LegacyTxType,
+			GasPrice: DefaultBNBGasPrice,
+		},
+		{
+			Type:       types.AccessListTxType,
+			GasPrice:   DefaultBNBGasPrice,
+			AccessList: types.AccessList{{Address: accessed}},
+		},
+		{
+			Type:      types.DynamicFeeTxType,
+			GasPrice:  HighGasPrice,
+			GasTipCap: DefaultBNBGasPrice,
+		},
+	}
+}
+
 func GenerateBNBTxsWithHighGas(arg *BidCaseArg, amountPerTx *big.Int, txcount int) types.Transactions {
-	bundleFactory := NewBidFactory(arg.Ctx, arg.Client, arg.RootPk, arg.BobPk, arg.Abc)
+	bundleFactory := arg.bidFactory()
 
 	txs := make([]*types.Transaction, 0)
 
@@ -167,7 +257,7 @@ func GenerateBNBTxsWithHighGas(arg *BidCaseArg, amountPerTx *big.Int, txcount in
 }
 
 func generateABCTxs(arg *BidCaseArg, amountPerTx *big.Int, txcount int) types.Transactions {
-	bundleFactory := NewBidFactory(arg.Ctx, arg.Client, arg.RootPk, arg.BobPk, arg.Abc)
+	bundleFactory := arg.bidFactory()
 
 	txs := make([]*types.Transaction, 0)
 
@@ -180,7 +270,47 @@ func generateABCTxs(arg *BidCaseArg, amountPerTx *big.Int, txcount int) types.Tr
 	return txs
 }
 
-func generateValidBid(arg *BidCaseArg, txs []*types.Transaction, gasUsed int64, gasFee *big.Int, payBuilder bool, builderFee *big.Int) *types.BidArgs {
+// bidFactory creates the factory of the txs of the case, sent by the senders of the case if
+// the accounts are drawn from Pool, otherwise by root.
+func (arg *BidCaseArg) bidFactory() *BidFactory {
+	return NewBidFactory(arg.Ctx, arg.FullNode, arg.Nonces, arg.Root, arg.Bob, arg.Abc).WithSenders(arg.senders)
+}
+
+// releaseNonces gives back the nonces of the txs, so the txs of a competing bid reuse them.
+func releaseNonces(arg *BidCaseArg, txs types.Transactions) {
+	if arg.Nonces == nil || len(txs) == 0 {
+		return
+	}
+
+	signer := types.LatestSignerForChainID(txs[0].ChainId())
+
+	// the first nonce and the count of each sender
+	type nonces struct {
+		first uint64
+		count int
+	}
+	bySender := make(map[common.Address]*nonces)
+	for _, tx := range txs {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			log.Errorw("types.Sender", "err", err)
+			return
+		}
+
+		n, ok := bySender[from]
+		if !ok {
+			bySender[from] = &nonces{first: tx.Nonce(), count: 1}
+			continue
+		}
+		n.count++
+	}
+
+	for from, n := range bySender {
+		arg.Nonces.Manager(from).Release(n.first, n.count)
+	}
+}
+
+func generateValidBid(arg *BidCaseArg, txs []*types.Transaction, gasUsed int64, gasFee *big.Int, payBuilder bool, builderFee *big.Int) (*types.BidArgs, error) {
 	txBytes := make([]hexutil.Bytes, 0)
 	for _, tx := range txs {
 		txByte, err := tx.MarshalBinary()
@@ -190,19 +320,16 @@ func generateValidBid(arg *BidCaseArg, txs []*types.Transaction, gasUsed int64,
 		txBytes = append(txBytes, txByte)
 	}
 
-	chainID, err := fullNode.ChainID(arg.Ctx)
-	for err != nil {
-		chainID, err = fullNode.ChainID(arg.Ctx)
-	}
+	chainID := arg.chainID()
 
-	blockNumber, err := fullNode.BlockNumber(arg.Ctx)
+	blockNumber, err := arg.FullNode.BlockNumber(arg.Ctx)
 	if err != nil {
-		log.Panicw("Client.BlockNumber", "err", err)
+		return nil, fmt.Errorf("failed to get block number, %v", err)
 	}
 
-	block, err := fullNode.BlockByNumber(arg.Ctx, big.NewInt(int64(blockNumber)))
+	block, err := arg.FullNode.BlockByNumber(arg.Ctx, big.NewInt(int64(blockNumber)))
 	if err != nil {
-		log.Panicw("Client.BlockByNumber", "err", err)
+		return nil, fmt.Errorf("failed to get block %v, %v", blockNumber, err)
 	}
 
 	rawBid := &types.RawBid{
@@ -217,14 +344,26 @@ func generateValidBid(arg *BidCaseArg, txs []*types.Transaction, gasUsed int64,
 		rawBid.BuilderFee = builderFee
 	}
 
-	bidArgs := arg.Builder.SignBid(rawBid)
-	if !payBuilder {
-		return bidArgs
+	bidArgs, err := arg.Builder.SignBid(rawBid)
+	if err != nil {
+		return nil, err
+	}
+
+	if payBuilder {
+		if bidArgs.PayBidTx, err = arg.payBidTx(rawBid.BlockNumber, chainID, builderFee); err != nil {
+			return nil, err
+		}
+		bidArgs.PayBidTxGasUsed = uint64(PayBidGasUsed)
+	}
+
+	// a bid not signed properly is a bug of the tooling, while some cases send txs can not be
+	// decoded on purpose, so only log it.
+	if err = VerifyBidSignature(bidArgs, arg.Builder.Address); err != nil {
+		return nil, fmt.Errorf("invalid bid signature, %v", err)
+	}
+	if err = VerifyBidTxs(bidArgs, chainID); err != nil {
+		log.Warnw("bid txs can not be decoded", "err", err)
 	}
 
-	validator := arg.Validators[0]
-	payBidTx := arg.Builder.PayBidTx(validator, chainID, builderFee)
-	bidArgs.PayBidTx = payBidTx
-	bidArgs.PayBidTxGasUsed = uint64(PayBidGasUsed)
-	return bidArgs
+	return bidArgs, nil
 }
@@ -0,0 +1,96 @@
+package cases
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/bnb-chain/bsc-mev-cases/log"
+)
+
+// FundConfig is the target balances of the accounts funded by FundAccounts.
+type FundConfig struct {
+	// BNB is the target balance in wei, BNB is not funded if nil
+	BNB *big.Int
+	// ABC is the target balance of the ABC token, ABC is not funded if nil or root has no ABC contract
+	ABC *big.Int
+	// Confirmations is the number of blocks waited after the block of the transfers
+	Confirmations uint64
+}
+
+// FundAccounts tops up the accounts from root up to the target balances of the config, the
+// transfers are sent to the tx pool of the client and waited for. It returns the transfers sent.
+func FundAccounts(ctx context.Context, client *ethclient.Client, root *Account, accounts []*Account,
+	config FundConfig) (types.Transactions, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := client.PendingNonceAt(ctx, root.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var txs types.Transactions
+	for _, acc := range accounts {
+		if config.BNB != nil {
+			balance, err := client.BalanceAt(ctx, acc.Address, nil)
+			if err != nil {
+				return txs, fmt.Errorf("balance of %v, %v", acc.Address, err)
+			}
+
+			if balance.Cmp(config.BNB) < 0 {
+				tx, err := root.TransferBNB(nonce, acc.Address, chainID, new(big.Int).Sub(config.BNB, balance))
+				if err != nil {
+					return txs, err
+				}
+
+				if err = client.SendTransaction(ctx, tx); err != nil {
+					return txs, fmt.Errorf("fund BNB of %v, %v", acc.Address, err)
+				}
+				txs = append(txs, tx)
+				nonce++
+			}
+		}
+
+		if config.ABC != nil && root.abc != nil {
+			balance, err := root.abc.BalanceOf(callOpts(), acc.Address)
+			if err != nil {
+				return txs, fmt.Errorf("ABC balance of %v, %v", acc.Address, err)
+			}
+
+			if balance.Cmp(config.ABC) < 0 {
+				tx, err := root.TransferABC(nonce, acc.Address, chainID, new(big.Int).Sub(config.ABC, balance))
+				if err != nil {
+					return txs, err
+				}
+
+				if err = client.SendTransaction(ctx, tx); err != nil {
+					return txs, fmt.Errorf("fund ABC of %v, %v", acc.Address, err)
+				}
+				txs = append(txs, tx)
+				nonce++
+			}
+		}
+	}
+
+	if len(txs) == 0 {
+		return nil, nil
+	}
+
+	log.Infow("funding accounts", "accounts", len(accounts), "txs", len(txs))
+	receipts, err := NewWaiter(client, config.Confirmations).WaitForReceipts(ctx, txs)
+	if err != nil {
+		return txs, err
+	}
+
+	if err = assertReceiptsSucceed(receipts); err != nil {
+		return txs, err
+	}
+
+	return txs, nil
+}
@@ -0,0 +1,359 @@
+package cases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/bnb-chain/bsc-mev-cases/log"
+)
+
+// BidErrorCodes are the codes of the errors documented for mev_sendBid.
+var BidErrorCodes = []int{
+	types.InvalidBidParamError,
+	types.InvalidPayBidTxError,
+	types.MevNotRunningError,
+	types.MevBusyError,
+	types.MevNotInTurnError,
+}
+
+const (
+	// fuzzTxCount is the number of txs of the valid bid fuzzed
+	fuzzTxCount = 10
+	// maxFuzzTxs is the max number of txs of an oversized bid
+	maxFuzzTxs = 2000
+	// maxFuzzMutations is the max number of mutations applied to a bid
+	maxFuzzMutations = 4
+)
+
+// CheckBidResponse checks mev_sendBid either accepts the bid or returns a json-rpc error
+// of BidErrorCodes with a message.
+func CheckBidResponse(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var bidErr rpc.Error
+	if !errors.As(err, &bidErr) {
+		return fmt.Errorf("not a json-rpc error, %v", err)
+	}
+
+	if bidErr.Error() == "" {
+		return fmt.Errorf("json-rpc error %v without message", bidErr.ErrorCode())
+	}
+
+	for _, code := range BidErrorCodes {
+		if bidErr.ErrorCode() == code {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("undocumented error code %v, %v", bidErr.ErrorCode(), bidErr)
+}
+
+// BidFuzzer mutates valid bids randomly, the same seed mutates the same bids the same way.
+type BidFuzzer struct {
+	rand    *rand.Rand
+	builder *Account
+	chainID *big.Int
+}
+
+// NewBidFuzzer creates the fuzzer, the builder re-signs the mutated bids at random.
+func NewBidFuzzer(seed int64, builder *Account, chainID *big.Int) *BidFuzzer {
+	return &BidFuzzer{
+		rand:    rand.New(rand.NewSource(seed)),
+		builder: builder,
+		chainID: chainID,
+	}
+}
+
+// Fuzz returns a copy of the bid with random fields, txs, signature or PayBidTx.
+func (f *BidFuzzer) Fuzz(bidArgs *types.BidArgs) (*types.BidArgs, error) {
+	generators := f.mutators()
+
+	mutators := make([]BidMutator, 0, maxFuzzMutations+1)
+	for i := f.rand.Intn(maxFuzzMutations) + 1; i > 0; i-- {
+		mutators = append(mutators, generators[f.rand.Intn(len(generators))]())
+	}
+
+	// re-signed or not, at any point of the mutations
+	if f.rand.Intn(2) == 0 {
+		at := f.rand.Intn(len(mutators) + 1)
+		mutators = append(mutators[:at], append([]BidMutator{f.resign()}, mutators[at:]...)...)
+	}
+
+	fuzzed, err := MutateBid(bidArgs, mutators...)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.rand.Intn(50) == 0 {
+		fuzzed.RawBid = nil
+	}
+
+	return fuzzed, nil
+}
+
+// resign re-signs the bid by the builder, a raw bid out of the range of rlp, e.g. of a negative
+// gasFee, can not be signed and keeps its signature.
+func (f *BidFuzzer) resign() BidMutator {
+	resign := Resign(f.builder)
+	return func(bidArgs *types.BidArgs) error {
+		if _, err := rlp.EncodeToBytes(bidArgs.RawBid); err != nil {
+			return nil
+		}
+		return resign(bidArgs)
+	}
+}
+
+// mutators returns the generators of the random mutations.
+func (f *BidFuzzer) mutators() []func() BidMutator {
+	return []func() BidMutator{
+		// raw bid
+		func() BidMutator { return WithBlockNumber(f.rand.Uint64()) },
+		func() BidMutator { return WithBlockOffset(int64(f.rand.Intn(7) - 3)) },
+		func() BidMutator { return WithParentHash(common.BytesToHash(f.bytes(common.HashLength))) },
+		func() BidMutator { return WithParentHash(common.Hash{}) },
+		func() BidMutator { return WithGasUsed(f.uint64()) },
+		func() BidMutator { return WithGasFee(f.bigInt()) },
+		func() BidMutator { return WithBuilderFee(f.bigInt()) },
+		func() BidMutator { return ScaleGasFee(big.NewRat(f.rand.Int63n(200), 100)) },
+
+		// txs
+		func() BidMutator { return f.mutateTx(func(tx []byte) []byte { return f.bytes(len(tx) * 2) }) },
+		func() BidMutator { return f.mutateTx(func(tx []byte) []byte { return tx[:f.rand.Intn(len(tx)+1)] }) },
+		func() BidMutator { return f.mutateTx(func(tx []byte) []byte { return nil }) },
+		func() BidMutator { return WithTxs(nil) },
+		func() BidMutator { return f.oversizeTxs() },
+
+		// signature
+		func() BidMutator { return WithSignature(f.bytes(crypto.SignatureLength * 2)) },
+		func() BidMutator { return WithSignature(f.fullBytes(crypto.SignatureLength)) },
+		func() BidMutator { return WithSignature(nil) },
+		func() BidMutator { return CorruptSignature() },
+
+		// PayBidTx
+		func() BidMutator { return DropPayBidTx() },
+		func() BidMutator { return WithPayBidTx(f.bytes(256)) },
+		func() BidMutator { return WithPayBidTxGasUsed(f.payBidTxGasUsed()) },
+		func() BidMutator {
+			receiver := common.BytesToAddress(f.fullBytes(common.AddressLength))
+			// a signer only signs a value of uint256
+			amount := new(big.Int).SetBytes(f.fullBytes(32))
+			nonce := f.rand.Uint64() % 4
+			return func(bidArgs *types.BidArgs) error {
+				payBidTx, err := f.builder.PayBidTx(nonce, receiver, f.chainID, amount)
+				if err != nil {
+					return fmt.Errorf("failed to sign PayBidTx, %v", err)
+				}
+				bidArgs.PayBidTx = payBidTx
+				return nil
+			}
+		},
+	}
+}
+
+// mutateTx replaces a random tx of the bid, or appends one if the bid has no tx.
+func (f *BidFuzzer) mutateTx(mutate func(tx []byte) []byte) BidMutator {
+	index := f.rand.Int()
+	return func(bidArgs *types.BidArgs) error {
+		txs := bidArgs.RawBid.Txs
+		if len(txs) == 0 {
+			bidArgs.RawBid.Txs = []hexutil.Bytes{mutate(f.bytes(128))}
+			return nil
+		}
+
+		txs[index%len(txs)] = mutate(txs[index%len(txs)])
+		return nil
+	}
+}
+
+// oversizeTxs repeats the txs of the bid up to maxFuzzTxs.
+func (f *BidFuzzer) oversizeTxs() BidMutator {
+	n := maxFuzzTxs/2 + f.rand.Intn(maxFuzzTxs/2)
+	return func(bidArgs *types.BidArgs) error {
+		txs := bidArgs.RawBid.Txs
+		if len(txs) == 0 {
+			txs = []hexutil.Bytes{f.bytes(128)}
+		}
+
+		oversized := make([]hexutil.Bytes, 0, n)
+		for i := 0; i < n; i++ {
+			oversized = append(oversized, txs[i%len(txs)])
+		}
+		bidArgs.RawBid.Txs = oversized
+		return nil
+	}
+}
+
+// bytes returns random bytes of random length up to max.
+func (f *BidFuzzer) bytes(max int) []byte {
+	return f.fullBytes(f.rand.Intn(max + 1))
+}
+
+func (f *BidFuzzer) fullBytes(n int) []byte {
+	b := make([]byte, n)
+	f.rand.Read(b)
+	return b
+}
+
+func (f *BidFuzzer) uint64() uint64 {
+	switch f.rand.Intn(4) {
+	case 0:
+		return 0
+	case 1:
+		return math.MaxUint64
+	case 2:
+		return uint64(f.rand.Intn(1000000))
+	default:
+		return f.rand.Uint64()
+	}
+}
+
+// bigInt returns nil, zero, negative, the bounds of uint256 or a random int.
+func (f *BidFuzzer) bigInt() *big.Int {
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+	switch f.rand.Intn(7) {
+	case 0:
+		return nil
+	case 1:
+		return new(big.Int)
+	case 2:
+		return big.NewInt(-f.rand.Int63())
+	case 3:
+		return maxUint256
+	case 4:
+		return new(big.Int).Add(maxUint256, big.NewInt(1))
+	case 5:
+		return new(big.Int).SetBytes(f.bytes(40))
+	default:
+		return big.NewInt(f.rand.Int63n(1e18))
+	}
+}
+
+func (f *BidFuzzer) payBidTxGasUsed() uint64 {
+	gasUsed := []uint64{0, uint64(BNBGasUsed), uint64(PayBidGasUsed), uint64(PayBidGasUsed) + 1}
+	if f.rand.Intn(len(gasUsed)+1) == len(gasUsed) {
+		return f.uint64()
+	}
+
+	return gasUsed[f.rand.Intn(len(gasUsed))]
+}
+
+// FuzzConfig configures a fuzz run.
+type FuzzConfig struct {
+	// Iterations is the number of fuzzed bids sent
+	Iterations int
+	// Seed of the mutations, a run is reproduced by the same seed against the same chain
+	Seed int64
+	// Dir is where the bids of unexpected responses are saved, not saved if empty
+	Dir string
+}
+
+// RunFuzz sends fuzzed bids, the case fails if any response is neither accepted nor
+// an error of BidErrorCodes.
+func RunFuzz(arg *BidCaseArg, config FuzzConfig) []*CaseResult {
+	log.Infow("fuzz", "seed", config.Seed, "iterations", config.Iterations)
+	return []*CaseResult{runCase(arg, &CaseInfo{
+		Name: "Fuzz",
+		Fn: func(arg *BidCaseArg) error {
+			return runFuzz(arg, config)
+		},
+	})}
+}
+
+func runFuzz(arg *BidCaseArg, config FuzzConfig) error {
+	fuzzer := NewBidFuzzer(config.Seed, arg.Builder, arg.chainID())
+	txs := GenerateBNBTxs(arg, TransferAmountPerTx, fuzzTxCount)
+	acc, err := simulateBid(arg, txs)
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	var first error
+	for i := 0; i < config.Iterations; i++ {
+		if err := arg.Ctx.Err(); err != nil {
+			return fmt.Errorf("fuzz stopped after %v bids, %w", i, err)
+		}
+
+		bidArgs, err := generateValidBid(arg, txs, int64(acc.GasUsed()), acc.GasFee(), i%2 == 1, BuilderFee)
+		if err != nil {
+			return err
+		}
+
+		if bidArgs, err = fuzzer.Fuzz(bidArgs); err != nil {
+			return err
+		}
+
+		_, err = arg.sendBid(bidArgs)
+		if err = CheckBidResponse(err); err == nil {
+			continue
+		}
+
+		failures++
+		if first == nil {
+			first = err
+		}
+
+		path := ""
+		if config.Dir != "" {
+			var er error
+			if path, er = SaveBid(config.Dir, bidArgs); er != nil {
+				log.Errorw("failed to save bid", "err", er)
+			}
+		}
+
+		log.Errorw("unexpected response of fuzzed bid", "err", err, "bid", path)
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%v of %v fuzzed bids got unexpected responses, first %v", failures, config.Iterations, first)
+	}
+
+	return nil
+}
+
+// SaveBid writes the bid as the params of mev_sendBid to a file in dir named by its content,
+// and returns the path.
+func SaveBid(dir string, bidArgs *types.BidArgs) (string, error) {
+	data, err := json.MarshalIndent(bidArgs, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("bid-%x.json", crypto.Keccak256(data)[:8]))
+	return path, os.WriteFile(path, data, 0o644)
+}
+
+// LoadBid reads a bid saved by SaveBid.
+func LoadBid(path string) (*types.BidArgs, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	bidArgs := new(types.BidArgs)
+	if err = json.Unmarshal(data, bidArgs); err != nil {
+		return nil, fmt.Errorf("failed to decode bid %v, %v", path, err)
+	}
+
+	return bidArgs, nil
+}
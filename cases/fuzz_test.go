@@ -0,0 +1,105 @@
+package cases
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/bsc-mev-cases/mevtest"
+)
+
+func TestCheckBidResponse(t *testing.T) {
+	assert.Nil(t, CheckBidResponse(nil))
+	assert.Nil(t, CheckBidResponse(mevtest.NewBidError(types.InvalidPayBidTxError, "invalid")))
+	assert.Nil(t, CheckBidResponse(types.ErrMevNotInTurn))
+
+	assert.ErrorContains(t, CheckBidResponse(mevtest.NewBidError(-32000, "boom")), "undocumented error code -32000")
+	assert.ErrorContains(t, CheckBidResponse(errors.New("connection refused")), "not a json-rpc error")
+}
+
+func TestBidFuzzer(t *testing.T) {
+	builderKey, _ := newTestKey()
+	builder := newAccount(builderKey, nil)
+	bidArgs, err := builder.SignBid(&types.RawBid{BlockNumber: 1, GasUsed: 21000})
+	assert.Nil(t, err)
+
+	// the same seed fuzzes the same way
+	a, b := NewBidFuzzer(7, builder, big.NewInt(56)), NewBidFuzzer(7, builder, big.NewInt(56))
+	for i := 0; i < 100; i++ {
+		fuzzedA, errA := a.Fuzz(bidArgs)
+		fuzzedB, errB := b.Fuzz(bidArgs)
+		assert.Nil(t, errA)
+		assert.Nil(t, errB)
+		assert.Equal(t, fuzzedA, fuzzedB)
+	}
+
+	assert.Equal(t, uint64(1), bidArgs.RawBid.BlockNumber)
+}
+
+func TestRunFuzz(t *testing.T) {
+	arg, server := newTestArg(t, 0)
+	dir := t.TempDir()
+
+	res := RunFuzz(arg, FuzzConfig{Iterations: 100, Seed: 1, Dir: dir})
+	assert.Equal(t, StatusPassed, res[0].Status, res[0].Error)
+
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+
+	// a generic error of the validator
+	server.InjectError(errors.New("boom"))
+	failed := runCaseFn(arg, "Fuzz", func(arg *BidCaseArg) error {
+		return runFuzz(arg, FuzzConfig{Iterations: 2, Seed: 1, Dir: dir})
+	})
+	assert.Equal(t, StatusFailed, failed.Status)
+	assert.Contains(t, failed.Error, "1 of 2 fuzzed bids got unexpected responses")
+
+	entries, err = os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+
+	bidArgs, err := LoadBid(filepath.Join(dir, entries[0].Name()))
+	assert.Nil(t, err)
+	assert.NotNil(t, bidArgs)
+}
+
+// FuzzSendBid checks the fake validator answers any fuzzed bid with an error of BidErrorCodes,
+// data is sent as a tx and as the signature of the bid.
+func FuzzSendBid(f *testing.F) {
+	arg, server := newTestArg(f, 0)
+	chainID := arg.chainID()
+	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 3)
+	acc, err := simulateBid(arg, txs)
+	assert.Nil(f, err)
+
+	f.Add(int64(0), []byte{})
+	f.Add(int64(1), []byte{0x02, 0xc0})
+	f.Add(int64(2), make([]byte, 65))
+	f.Add(int64(3), []byte("invalid signature"))
+
+	f.Fuzz(func(t *testing.T, seed int64, data []byte) {
+		bidArgs, err := generateValidBid(arg, txs, int64(acc.GasUsed()), acc.GasFee(), seed%2 == 1, BuilderFee)
+		assert.Nil(t, err)
+		fuzzed, err := NewBidFuzzer(seed, arg.Builder, chainID).Fuzz(bidArgs)
+		assert.Nil(t, err)
+		moreTxs, err := MutateBid(bidArgs, WithTxs(append(bidArgs.RawBid.Txs, data)))
+		assert.Nil(t, err)
+		signature, err := MutateBid(bidArgs, WithSignature(data))
+		assert.Nil(t, err)
+
+		for _, bid := range []*types.BidArgs{fuzzed, moreTxs, signature} {
+			_, err := arg.Client.SendBid(arg.Ctx, *bid)
+			assert.Nil(t, CheckBidResponse(err))
+		}
+
+		// the validator seals the bids accepted
+		_, err = server.Chain().Seal()
+		assert.Nil(t, err)
+	})
+}
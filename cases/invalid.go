@@ -1,54 +1,191 @@
 package cases
 
 import (
-	"context"
 	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/bnb-chain/bsc-mev-cases/log"
 )
 
-var invalidBidCases = map[string]BidCaseFn{
-	"InvalidBid_OldBlockNumber_20":               InvalidBid_OldBlockNumber_20,
-	"InvalidBid_FutureNumber_20":                 InvalidBid_FutureNumber_20,
-	"InvalidBid_NilNumber_20":                    InvalidBid_NilNumber_20,
-	"InvalidBid_InvalidParentHash_20":            InvalidBid_InvalidParentHash_20,
-	"InvalidBid_EmptyTxs_20":                     InvalidBid_EmptyTxs_20,
-	"InvalidBid_IllegalTxs_3":                    InvalidBid_IllegalTxs_3,
-	"InvalidBid_IllegalTxs_20":                   InvalidBid_IllegalTxs_20,
-	"InvalidBid_FailedTx_20":                     InvalidBid_FailedTx_20,
-	"InvalidBid_GasExceed_10000":                 InvalidBid_GasExceed_10000,
-	"InvalidBid_NilGasUsed_20":                   InvalidBid_NilGasUsed_20,
-	"InvalidBid_LessGasFee_20":                   InvalidBid_LessGasFee_20,
-	"InvalidBid_MoreGasFee_20":                   InvalidBid_MoreGasFee_20,
-	"InvalidBid_NilGasFee_20":                    InvalidBid_NilGasFee_20,
-	"InvalidBid_EmptyGasFee_20":                  InvalidBid_EmptyGasFee_20,
-	"InvalidBid_InvalidSignature_20":             InvalidBid_InvalidSignature_20,
-	"InvalidBid_ExpensiveBuilderFee_20":          InvalidBid_ExpensiveBuilderFee_20,
-	"InvalidBid_NilPayBidTx_NonNilPayGasUsed_20": InvalidBid_NilPayBidTx_NonNilPayGasUsed_20,
-	"InvalidBid_NonNilPayBidTx_NilPayGasUsed_20": InvalidBid_NonNilPayBidTx_NilPayGasUsed_20,
-
-	//"InvalidBid_LessGasUsed_20":                  InvalidBid_LessGasUsed_20,
-	//"InvalidBid_MoreGasUsed_20":                  InvalidBid_MoreGasUsed_20,
+func init() {
+	Register(&CaseInfo{
+		Name:          "InvalidBid_OldBlockNumber_20",
+		Description:   "bid for a block number 10 blocks behind",
+		Tags:          []Tag{TagInvalid, TagStable},
+		Expect:        ExpectInvalidBidParam,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_OldBlockNumber_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_FutureNumber_20",
+		Description:   "bid for a block number 100 blocks ahead",
+		Tags:          []Tag{TagInvalid},
+		Expect:        ExpectInvalidBidParam,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_FutureNumber_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_NilNumber_20",
+		Description:   "bid with zero block number",
+		Tags:          []Tag{TagInvalid},
+		Expect:        ExpectInvalidBidParam,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_NilNumber_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_InvalidParentHash_20",
+		Description:   "bid with empty parent hash",
+		Tags:          []Tag{TagInvalid},
+		Expect:        ExpectInvalidBidParam,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_InvalidParentHash_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_EmptyTxs_20",
+		Description:   "bid claiming gas of 20 txs without any tx",
+		Tags:          []Tag{TagInvalid},
+		Expect:        ExpectNoError,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_EmptyTxs_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_IllegalTxs_3",
+		Description:   "bid of 3 unsigned txs",
+		Tags:          []Tag{TagInvalid},
+		Expect:        ExpectInvalidBidParam,
+		EstimatedCost: 0.0063,
+		Fn:            InvalidBid_IllegalTxs_3,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_IllegalTxs_20",
+		Description:   "bid of 20 unsigned txs",
+		Tags:          []Tag{TagInvalid, TagStable},
+		Expect:        ExpectInvalidBidParam,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_IllegalTxs_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_FailedTx_20",
+		Description:   "bid of 20 txs transferring more than the balance",
+		Tags:          []Tag{TagInvalid},
+		Expect:        ExpectNoError,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_FailedTx_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_GasExceed_10000",
+		Description:   "bid of 10000 txs exceeding the block gas limit",
+		Tags:          []Tag{TagInvalid, TagStable},
+		Expect:        ExpectNoError,
+		EstimatedCost: 21,
+		Fn:            InvalidBid_GasExceed_10000,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_NilGasUsed_20",
+		Description:   "bid with zero gasUsed",
+		Tags:          []Tag{TagInvalid},
+		Expect:        ExpectInvalidBidParam,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_NilGasUsed_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_LessGasFee_20",
+		Description:   "bid claiming less gasFee than it pays",
+		Tags:          []Tag{TagInvalid},
+		Expect:        ExpectNoError,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_LessGasFee_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_MoreGasFee_20",
+		Description:   "bid claiming more gasFee than it pays",
+		Tags:          []Tag{TagInvalid},
+		Expect:        ExpectNoError,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_MoreGasFee_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_NilGasFee_20",
+		Description:   "bid with nil gasFee",
+		Tags:          []Tag{TagInvalid},
+		Expect:        ExpectInvalidBidParam,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_NilGasFee_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_EmptyGasFee_20",
+		Description:   "bid with zero gasFee",
+		Tags:          []Tag{TagInvalid},
+		Expect:        ExpectInvalidBidParam,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_EmptyGasFee_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_InvalidSignature_20",
+		Description:   "bid with a malformed signature",
+		Tags:          []Tag{TagInvalid},
+		Expect:        ExpectInvalidBidParam,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_InvalidSignature_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_ExpensiveBuilderFee_20",
+		Description:   "bid with builderFee greater than gasFee",
+		Tags:          []Tag{TagInvalid, TagPayBid},
+		Expect:        ExpectInvalidBidParam,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_ExpensiveBuilderFee_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_NilPayBidTx_NonNilPayGasUsed_20",
+		Description:   "bid with PayBidTxGasUsed but without PayBidTx",
+		Tags:          []Tag{TagInvalid, TagPayBid},
+		Expect:        ExpectInvalidPayBidTx,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_NilPayBidTx_NonNilPayGasUsed_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_NonNilPayBidTx_NilPayGasUsed_20",
+		Description:   "bid with PayBidTx but without PayBidTxGasUsed",
+		Tags:          []Tag{TagInvalid, TagPayBid},
+		Expect:        ExpectInvalidPayBidTx,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_NonNilPayBidTx_NilPayGasUsed_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_MixedTxTypes_FeeCapGasFee_30",
+		Description:   "bid of mixed tx types claiming gasFee by the fee cap instead of the effective gas price",
+		Tags:          []Tag{TagInvalid, TagTxTypes},
+		Expect:        ExpectNoError,
+		EstimatedCost: 0.0654,
+		Fn:            InvalidBid_MixedTxTypes_FeeCapGasFee_30,
+	})
+
+	Register(&CaseInfo{
+		Name:          "InvalidBid_LessGasUsed_20",
+		Description:   "bid claiming half of the simulated gasUsed",
+		Tags:          []Tag{TagInvalid},
+		Expect:        ExpectNotSealed,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_LessGasUsed_20,
+	})
+	Register(&CaseInfo{
+		Name:          "InvalidBid_MoreGasUsed_20",
+		Description:   "bid claiming half more than the simulated gasUsed",
+		Tags:          []Tag{TagInvalid},
+		Expect:        ExpectNotSealed,
+		EstimatedCost: 0.042,
+		Fn:            InvalidBid_MoreGasUsed_20,
+	})
 }
 
-func RunInvalidCases(arg *BidCaseArg) {
-	for n, c := range invalidBidCases {
-		waitForInTurn(arg)
-		print("run case ", n)
-		err := c(arg)
-		if err != nil {
-			print(" failed: ", err.Error())
-		} else {
-			print(" succeed")
-		}
-		println()
-	}
+// RunInvalidCases runs the cases tagged invalid.
+func RunInvalidCases(arg *BidCaseArg) []*CaseResult {
+	return RunCases(arg, TagInvalid)
 }
 
 // InvalidBid_OldBlockNumber_20
@@ -57,13 +194,18 @@ func InvalidBid_OldBlockNumber_20(arg *BidCaseArg) error {
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 20)
 	gasUsed := BNBGasUsed * 20
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-	bidArgs.RawBid.BlockNumber -= 10
+	mutators := []BidMutator{WithBlockOffset(-10), Resign(arg.Builder)}
+	bidArgs, err := generateMutatedBid(arg, txs, gasUsed, gasFee, false, nil, mutators...)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		retry, err = assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
+	retry, err := assertInvalidBidParam(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateMutatedBid(arg, txs, gasUsed, gasFee, false, nil, mutators...); err != nil {
+			return err
+		}
+		retry, err = assertInvalidBidParam(arg, bidArgs)
 	}
 	return err
 }
@@ -74,13 +216,18 @@ func InvalidBid_FutureNumber_20(arg *BidCaseArg) error {
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 20)
 	gasUsed := BNBGasUsed * 20
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-	bidArgs.RawBid.BlockNumber += 100
+	mutators := []BidMutator{WithBlockOffset(100), Resign(arg.Builder)}
+	bidArgs, err := generateMutatedBid(arg, txs, gasUsed, gasFee, false, nil, mutators...)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		retry, err = assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
+	retry, err := assertInvalidBidParam(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateMutatedBid(arg, txs, gasUsed, gasFee, false, nil, mutators...); err != nil {
+			return err
+		}
+		retry, err = assertInvalidBidParam(arg, bidArgs)
 	}
 	return err
 }
@@ -91,13 +238,18 @@ func InvalidBid_NilNumber_20(arg *BidCaseArg) error {
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 20)
 	gasUsed := BNBGasUsed * 20
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-	bidArgs.RawBid.BlockNumber = 0
+	mutators := []BidMutator{WithBlockNumber(0), Resign(arg.Builder)}
+	bidArgs, err := generateMutatedBid(arg, txs, gasUsed, gasFee, false, nil, mutators...)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		retry, err = assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
+	retry, err := assertInvalidBidParam(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateMutatedBid(arg, txs, gasUsed, gasFee, false, nil, mutators...); err != nil {
+			return err
+		}
+		retry, err = assertInvalidBidParam(arg, bidArgs)
 	}
 	return err
 }
@@ -108,13 +260,18 @@ func InvalidBid_InvalidParentHash_20(arg *BidCaseArg) error {
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 20)
 	gasUsed := BNBGasUsed * 20
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-	bidArgs.RawBid.ParentHash = common.Hash{}
+	mutators := []BidMutator{WithParentHash(common.Hash{}), Resign(arg.Builder)}
+	bidArgs, err := generateMutatedBid(arg, txs, gasUsed, gasFee, false, nil, mutators...)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		retry, err = assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
+	retry, err := assertInvalidBidParam(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateMutatedBid(arg, txs, gasUsed, gasFee, false, nil, mutators...); err != nil {
+			return err
+		}
+		retry, err = assertInvalidBidParam(arg, bidArgs)
 	}
 	return err
 }
@@ -122,17 +279,26 @@ func InvalidBid_InvalidParentHash_20(arg *BidCaseArg) error {
 // InvalidBid_EmptyTxs_20
 // gasFee = 21000 * 20 * 0.0000001 BNB = 0.042 BNB
 func InvalidBid_EmptyTxs_20(arg *BidCaseArg) error {
+	mark := arg.logMark()
 	gasUsed := BNBGasUsed * 20
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := generateValidBid(arg, nil, gasUsed, gasFee, false, nil)
+	bidArgs, err := generateValidBid(arg, nil, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertNoError(arg.Ctx, arg.Client, bidArgs, nil)
-	for retry {
-		bidArgs = generateValidBid(arg, nil, gasUsed, gasFee, false, nil)
-		retry, err = assertNoError(arg.Ctx, arg.Client, bidArgs, nil)
+	retry, err := assertNoError(arg, bidArgs, nil)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, nil, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertNoError(arg, bidArgs, nil)
 	}
-	return err
-	// TODO check no err log
+	if err != nil {
+		return err
+	}
+
+	return arg.expectNoLog(mark, LogBidErrors)
 }
 
 // InvalidBid_IllegalTxs_3
@@ -141,12 +307,17 @@ func InvalidBid_IllegalTxs_3(arg *BidCaseArg) error {
 	txs := generateBNBTxsNoSign(arg, TransferAmountPerTx, 3)
 	gasUsed := BNBGasUsed * 3
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		retry, err = assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
+	retry, err := assertInvalidBidParam(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertInvalidBidParam(arg, bidArgs)
 	}
 	return err
 }
@@ -157,12 +328,17 @@ func InvalidBid_IllegalTxs_20(arg *BidCaseArg) error {
 	txs := generateBNBTxsNoSign(arg, TransferAmountPerTx, 20)
 	gasUsed := BNBGasUsed * 20
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		retry, err = assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
+	retry, err := assertInvalidBidParam(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertInvalidBidParam(arg, bidArgs)
 	}
 	return err
 }
@@ -170,62 +346,104 @@ func InvalidBid_IllegalTxs_20(arg *BidCaseArg) error {
 // InvalidBid_FailedTx_20
 // gasFee = 21000 * 20 * 0.0000001 BNB = 0.042 BNB
 func InvalidBid_FailedTx_20(arg *BidCaseArg) error {
+	mark := arg.logMark()
 	txs := generateBNBFailedTxs(arg, 20)
 	gasUsed := BNBGasUsed * 20
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertNoError(arg.Ctx, arg.Client, bidArgs, nil)
-	for retry {
-		bidArgs = generateValidBid(arg, nil, gasUsed, gasFee, false, nil)
-		retry, err = assertNoError(arg.Ctx, arg.Client, bidArgs, nil)
+	retry, err := assertNoError(arg, bidArgs, nil)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertNoError(arg, bidArgs, nil)
 	}
-	return err
-	// TODO check has err log: insufficient funds
+	if err != nil {
+		return err
+	}
+
+	return arg.expectLog(mark, LogInsufficientFunds)
 }
 
 // InvalidBid_GasExceed_10000
 // gasFee = 21000 * 10000 * 0.0000001 BNB = 0.042*500 BNB
 func InvalidBid_GasExceed_10000(arg *BidCaseArg) error {
+	mark := arg.logMark()
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 10000)
 	gasUsed := BNBGasUsed * 10000
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
+
+	retry, err := assertNoError(arg, bidArgs, nil)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertNoError(arg, bidArgs, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	return arg.expectLog(mark, LogGasLimitReached)
+}
+
+// InvalidBid_LessGasUsed_20
+// gasUsed = 21000 * 10, half of the simulated
+// gasFee = 21000 * 20 * 0.0000001 BNB = 0.042 BNB
+func InvalidBid_LessGasUsed_20(arg *BidCaseArg) error {
+	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 20)
+	acc, err := simulateBid(arg, txs)
+	if err != nil {
+		return err
+	}
+	gasUsed, gasFee := int64(acc.GasUsed()/2), acc.GasFee()
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertNoError(arg.Ctx, arg.Client, bidArgs, nil)
-	for retry {
-		bidArgs = generateValidBid(arg, nil, gasUsed, gasFee, false, nil)
-		retry, err = assertNoError(arg.Ctx, arg.Client, bidArgs, nil)
+	retry, err := assertNotSealed(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertNotSealed(arg, bidArgs)
 	}
 	return err
-	// TODO check has err log: gas limit reached
 }
 
-//// InvalidBid_LessGasUsed_20
-//// gasFee = 21000 * 20 * 0.0000001 BNB = 0.042 BNB
-//func InvalidBid_LessGasUsed_20(arg *BidCaseArg) error {
-//	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 20)
-//	gasUsed := BNBGasUsed * 10
-//	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-//	_ = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-//
-//	// expect: Builder receive issue
-//
-//	return nil
-//}
-//
-//// InvalidBid_MoreGasUsed_20
-//// gasFee = 21000 * 20 * 0.0000001 BNB = 0.042 BNB
-//func InvalidBid_MoreGasUsed_20(arg *BidCaseArg) error {
-//	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 20)
-//	gasUsed := BNBGasUsed * 30
-//	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-//	_ = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-//
-//	// expect: Builder receive issue
-//
-//	return nil
-//}
+// InvalidBid_MoreGasUsed_20
+// gasUsed = 21000 * 30, half more than the simulated
+// gasFee = 21000 * 20 * 0.0000001 BNB = 0.042 BNB
+func InvalidBid_MoreGasUsed_20(arg *BidCaseArg) error {
+	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 20)
+	acc, err := simulateBid(arg, txs)
+	if err != nil {
+		return err
+	}
+	gasUsed, gasFee := int64(acc.GasUsed()+acc.GasUsed()/2), acc.GasFee()
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
+
+	retry, err := assertNotSealed(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertNotSealed(arg, bidArgs)
+	}
+	return err
+}
 
 // InvalidBid_NilGasUsed_20
 // gasFee = 21000 * 20 * 0.0000001 BNB = 0.042 BNB
@@ -233,12 +451,17 @@ func InvalidBid_NilGasUsed_20(arg *BidCaseArg) error {
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 20)
 	gasUsed := int64(0)
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		retry, err = assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
+	retry, err := assertInvalidBidParam(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertInvalidBidParam(arg, bidArgs)
 	}
 	return err
 }
@@ -246,35 +469,53 @@ func InvalidBid_NilGasUsed_20(arg *BidCaseArg) error {
 // InvalidBid_LessGasFee_20
 // gasFee = 21000 * 20 * 0.0000001 BNB = 0.042 BNB
 func InvalidBid_LessGasFee_20(arg *BidCaseArg) error {
+	mark := arg.logMark()
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 20)
 	gasUsed := BNBGasUsed * 20
 	gasFee := big.NewInt(gasUsed * big.NewInt(1e9).Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertNoError(arg.Ctx, arg.Client, bidArgs, nil)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		retry, err = assertNoError(arg.Ctx, arg.Client, bidArgs, nil)
+	retry, err := assertNoError(arg, bidArgs, nil)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertNoError(arg, bidArgs, nil)
 	}
-	return err
-	// TODO check succeed when idle
+	if err != nil {
+		return err
+	}
+
+	return arg.expectNoLog(mark, LogBidErrors)
 }
 
 // InvalidBid_MoreGasFee_20
 // gasFee = 21000 * 20 * 0.0000001 BNB = 0.042 BNB
 func InvalidBid_MoreGasFee_20(arg *BidCaseArg) error {
+	mark := arg.logMark()
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 20)
 	gasUsed := BNBGasUsed * 20
 	gasFee := big.NewInt(gasUsed * big.NewInt(1e12).Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertNoError(arg.Ctx, arg.Client, bidArgs, nil)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		retry, err = assertNoError(arg.Ctx, arg.Client, bidArgs, nil)
+	retry, err := assertNoError(arg, bidArgs, nil)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertNoError(arg, bidArgs, nil)
 	}
-	return err
-	// TODO check has err log: invalid reward
+	if err != nil {
+		return err
+	}
+
+	return arg.expectLog(mark, LogInvalidReward)
 }
 
 // InvalidBid_NilGasFee_20
@@ -282,12 +523,17 @@ func InvalidBid_MoreGasFee_20(arg *BidCaseArg) error {
 func InvalidBid_NilGasFee_20(arg *BidCaseArg) error {
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 20)
 	gasUsed := BNBGasUsed * 20
-	bidArgs := generateValidBid(arg, txs, gasUsed, nil, false, nil)
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, nil, false, nil)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, nil, false, nil)
-		retry, err = assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
+	retry, err := assertInvalidBidParam(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, nil, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertInvalidBidParam(arg, bidArgs)
 	}
 	return err
 }
@@ -298,12 +544,17 @@ func InvalidBid_EmptyGasFee_20(arg *BidCaseArg) error {
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 20)
 	gasUsed := BNBGasUsed * 20
 	gasFee := big.NewInt(0)
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		retry, err = assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
+	retry, err := assertInvalidBidParam(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertInvalidBidParam(arg, bidArgs)
 	}
 	return err
 }
@@ -314,14 +565,18 @@ func InvalidBid_InvalidSignature_20(arg *BidCaseArg) error {
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 20)
 	gasUsed := BNBGasUsed * 20
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-	bidArgs.Signature = []byte("invalid signature")
+	mutators := []BidMutator{CorruptSignature()}
+	bidArgs, err := generateMutatedBid(arg, txs, gasUsed, gasFee, false, nil, mutators...)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		bidArgs.Signature = []byte("invalid signature")
-		retry, err = assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
+	retry, err := assertInvalidBidParam(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateMutatedBid(arg, txs, gasUsed, gasFee, false, nil, mutators...); err != nil {
+			return err
+		}
+		retry, err = assertInvalidBidParam(arg, bidArgs)
 	}
 	return err
 }
@@ -333,12 +588,17 @@ func InvalidBid_ExpensiveBuilderFee_20(arg *BidCaseArg) error {
 	gasUsed := BNBGasUsed * 20
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
 	builderFee := big.NewInt(gasUsed*DefaultBNBGasPrice.Int64() + 1)
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, true, builderFee)
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, true, builderFee)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, true, builderFee)
-		retry, err = assertInvalidBidParam(arg.Ctx, arg.Client, bidArgs)
+	retry, err := assertInvalidBidParam(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, true, builderFee); err != nil {
+			return err
+		}
+		retry, err = assertInvalidBidParam(arg, bidArgs)
 	}
 
 	return err
@@ -351,14 +611,18 @@ func InvalidBid_NilPayBidTx_NonNilPayGasUsed_20(arg *BidCaseArg) error {
 	gasUsed := BNBGasUsed * 20
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
 	builderFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64() / 5)
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, true, builderFee)
-	bidArgs.PayBidTx = nil
+	mutators := []BidMutator{DropPayBidTx()}
+	bidArgs, err := generateMutatedBid(arg, txs, gasUsed, gasFee, true, builderFee, mutators...)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertInvalidPayBidTx(arg.Ctx, arg.Client, bidArgs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, true, builderFee)
-		bidArgs.PayBidTx = nil
-		retry, err = assertInvalidPayBidTx(arg.Ctx, arg.Client, bidArgs)
+	retry, err := assertInvalidPayBidTx(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateMutatedBid(arg, txs, gasUsed, gasFee, true, builderFee, mutators...); err != nil {
+			return err
+		}
+		retry, err = assertInvalidPayBidTx(arg, bidArgs)
 	}
 
 	return err
@@ -371,21 +635,57 @@ func InvalidBid_NonNilPayBidTx_NilPayGasUsed_20(arg *BidCaseArg) error {
 	gasUsed := BNBGasUsed * 20
 	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
 	builderFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64() / 5)
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, true, builderFee)
-	bidArgs.PayBidTxGasUsed = 0
+	mutators := []BidMutator{WithPayBidTxGasUsed(0)}
+	bidArgs, err := generateMutatedBid(arg, txs, gasUsed, gasFee, true, builderFee, mutators...)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertInvalidPayBidTx(arg.Ctx, arg.Client, bidArgs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, true, builderFee)
-		bidArgs.PayBidTx = nil
-		retry, err = assertInvalidPayBidTx(arg.Ctx, arg.Client, bidArgs)
+	retry, err := assertInvalidPayBidTx(arg, bidArgs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateMutatedBid(arg, txs, gasUsed, gasFee, true, builderFee, mutators...); err != nil {
+			return err
+		}
+		retry, err = assertInvalidPayBidTx(arg, bidArgs)
 	}
 
 	return err
 }
 
+// InvalidBid_MixedTxTypes_FeeCapGasFee_30
+// gasFee = (21000 * 20 + 2400 * 10) * 0.0000001 BNB + 21000 * 10 * 0.000001 BNB = 0.2544 BNB
+// while txs pay 0.0654 BNB
+func InvalidBid_MixedTxTypes_FeeCapGasFee_30(arg *BidCaseArg) error {
+	mark := arg.logMark()
+	bob := arg.Bob.Address()
+	txs := GenerateBNBTxsWithOptions(arg, TransferAmountPerTx, 30, MixedTxOptions(bob)...)
+	acc, err := simulateBid(arg, txs)
+	if err != nil {
+		return err
+	}
+	// the gas price of a dynamic fee tx is its fee cap
+	gasUsed, gasFee := int64(acc.GasUsed()), acc.gasFee(nil)
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
+
+	retry, err := assertNoError(arg, bidArgs, nil)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertNoError(arg, bidArgs, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	return arg.expectLog(mark, LogInvalidReward)
+}
+
 func generateBNBTxsNoSign(arg *BidCaseArg, amountPerTx *big.Int, txcount int) types.Transactions {
-	bundleFactory := NewBidFactory(arg.Ctx, arg.Client, arg.RootPk, arg.BobPk, arg.Abc)
+	bundleFactory := NewBidFactory(arg.Ctx, arg.FullNode, arg.Nonces, arg.Root, arg.Bob, arg.Abc)
 	root := bundleFactory.Root()
 	bob := bundleFactory.Bob()
 
@@ -400,9 +700,20 @@ func generateBNBTxsNoSign(arg *BidCaseArg, amountPerTx *big.Int, txcount int) ty
 	return txs
 }
 
-func assertInvalidBidParam(ctx context.Context, client *ethclient.Client, bidArgs *types.BidArgs) (
+func assertInvalidBidParam(arg *BidCaseArg, bidArgs *types.BidArgs) (
+	bool, error) {
+	return assertErrorCode(arg, bidArgs, types.InvalidBidParamError)
+}
+
+func assertInvalidPayBidTx(arg *BidCaseArg, bidArgs *types.BidArgs) (
+	bool, error) {
+	return assertErrorCode(arg, bidArgs, types.InvalidPayBidTxError)
+}
+
+// assertErrorCode checks mev_sendBid returns the error code, it retries on any other code.
+func assertErrorCode(arg *BidCaseArg, bidArgs *types.BidArgs, code int) (
 	bool, error) {
-	_, err := client.SendBid(ctx, *bidArgs)
+	_, err := arg.sendBid(bidArgs)
 	if err == nil {
 		return false, fmt.Errorf("expect error but return nil")
 	}
@@ -412,35 +723,43 @@ func assertInvalidBidParam(ctx context.Context, client *ethclient.Client, bidArg
 		return false, fmt.Errorf("expect jsonrpc error but not")
 	}
 
-	if bidErr.ErrorCode() == types.InvalidBidParamError {
+	if bidErr.ErrorCode() == code {
 		return false, nil
 	}
 
 	return true, bidErr
 }
 
-func assertInvalidPayBidTx(ctx context.Context, client *ethclient.Client, bidArgs *types.BidArgs) (
-	bool, error) {
-	_, err := client.SendBid(ctx, *bidArgs)
-	if err == nil {
-		return false, fmt.Errorf("expect error but return nil")
+// assertNotSealed sends the bid expecting no error, and checks the block of the bid is sealed
+// without it, e.g. the validator reports the issue of a bid of a wrong gasUsed to the builder.
+func assertNotSealed(arg *BidCaseArg, bidArgs *types.BidArgs) (bool, error) {
+	retry, err := assertNoError(arg, bidArgs, nil)
+	if retry || err != nil {
+		return retry, err
 	}
 
-	bidErr, ok := err.(rpc.Error)
-	if !ok {
-		return false, fmt.Errorf("expect jsonrpc error but not")
+	number := bidArgs.RawBid.BlockNumber
+	block, err := arg.waiter().WaitForBlock(arg.Ctx, number)
+	if err != nil {
+		return false, err
 	}
 
-	if bidErr.ErrorCode() == types.InvalidPayBidTxError {
-		return false, nil
+	// the bid is not sealed on another parent anyway
+	if block.ParentHash() != bidArgs.RawBid.ParentHash {
+		log.Infow("retry", "reason", "block sealed on another parent", "number", number)
+		return true, fmt.Errorf("block %v is on parent %v, bid on %v", number, block.ParentHash(), bidArgs.RawBid.ParentHash)
 	}
 
-	return true, bidErr
+	if AssertBidInBlock(block, bidArgs) == nil {
+		return false, fmt.Errorf("bid of gasUsed %v sealed in block %v", bidArgs.RawBid.GasUsed, number)
+	}
+
+	return false, nil
 }
 
-func assertNoError(ctx context.Context, client *ethclient.Client, bidArgs *types.BidArgs, txs types.Transactions) (
+func assertNoError(arg *BidCaseArg, bidArgs *types.BidArgs, txs types.Transactions) (
 	bool, error) {
-	_, err := client.SendBid(ctx, *bidArgs)
+	_, err := arg.sendBid(bidArgs)
 	if err != nil {
 		bidErr, ok := err.(rpc.Error)
 		if !ok {
@@ -453,6 +772,12 @@ func assertNoError(ctx context.Context, client *ethclient.Client, bidArgs *types
 			log.Infow("retry", "reason", "InvalidBidParamError or InvalidPayBidTxError")
 			return true, err
 		}
+
+		// the bid is sent to the validator in turn for its block, which is sealed already
+		if bidErr.ErrorCode() == types.MevNotInTurnError {
+			log.Infow("retry", "reason", "MevNotInTurnError")
+			return true, err
+		}
 	}
 
 	return false, nil
@@ -0,0 +1,502 @@
+package cases
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/bnb-chain/bsc-mev-cases/log"
+)
+
+// headCacheTTL is how long the head block is reused by the bids of a load run.
+const headCacheTTL = 100 * time.Millisecond
+
+// BidSize is a number of txs of the bids and its weight in the distribution of the sizes.
+type BidSize struct {
+	Txs    int
+	Weight int
+}
+
+// ParseBidSizes parses comma separated txs:weight, e.g. 8:2,32:1,512:1, the weight is 1 if omitted.
+func ParseBidSizes(s string) ([]BidSize, error) {
+	var sizes []BidSize
+	for _, field := range strings.Split(s, ",") {
+		txs, weight, found := strings.Cut(strings.TrimSpace(field), ":")
+
+		size := BidSize{Weight: 1}
+		var err error
+		if size.Txs, err = strconv.Atoi(txs); err != nil || size.Txs <= 0 {
+			return nil, fmt.Errorf("invalid bid size %q", field)
+		}
+
+		if found {
+			if size.Weight, err = strconv.Atoi(weight); err != nil || size.Weight <= 0 {
+				return nil, fmt.Errorf("invalid weight of bid size %q", field)
+			}
+		}
+
+		sizes = append(sizes, size)
+	}
+
+	return sizes, nil
+}
+
+// pickBidSize picks a size at random by the weights.
+func pickBidSize(r *rand.Rand, sizes []BidSize) int {
+	total := 0
+	for _, size := range sizes {
+		total += size.Weight
+	}
+
+	n := r.Intn(total)
+	for _, size := range sizes {
+		if n < size.Weight {
+			return size.Txs
+		}
+		n -= size.Weight
+	}
+
+	return sizes[len(sizes)-1].Txs
+}
+
+// latencySamples is the number of latencies a LatencyHistogram keeps for its quantiles.
+const latencySamples = 4096
+
+// LatencyHistogram collects latencies for their quantiles, exact up to latencySamples latencies and
+// estimated from a uniform sample of them beyond, so a long run takes bounded memory. It is safe for
+// concurrent use.
+type LatencyHistogram struct {
+	mu      sync.Mutex
+	rand    *rand.Rand
+	samples []time.Duration
+	sorted  bool
+	count   int
+	max     time.Duration
+}
+
+// Observe adds a latency.
+func (h *LatencyHistogram) Observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.count++
+	if d > h.max {
+		h.max = d
+	}
+
+	if len(h.samples) < latencySamples {
+		h.samples = append(h.samples, d)
+		h.sorted = false
+		return
+	}
+
+	// reservoir sampling, every latency observed is kept with the same probability
+	if h.rand == nil {
+		h.rand = rand.New(rand.NewSource(1))
+	}
+	if i := h.rand.Intn(h.count); i < latencySamples {
+		h.samples[i] = d
+		h.sorted = false
+	}
+}
+
+// Count returns the number of latencies observed.
+func (h *LatencyHistogram) Count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.count
+}
+
+// Quantile returns the latency of the quantile q in [0, 1] by the nearest rank, 0 if none observed.
+// The quantile 1 is the max latency observed.
+func (h *LatencyHistogram) Quantile(q float64) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.samples) == 0 {
+		return 0
+	}
+	if q >= 1 {
+		return h.max
+	}
+
+	if !h.sorted {
+		sort.Slice(h.samples, func(i, j int) bool { return h.samples[i] < h.samples[j] })
+		h.sorted = true
+	}
+
+	rank := int(math.Ceil(q*float64(len(h.samples)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+
+	return h.samples[rank]
+}
+
+// LoadConfig configures a load run.
+type LoadConfig struct {
+	// Rate is the target bids per second of all the builders
+	Rate float64
+	// Builders is the number of builder identities, arg.Builder and the first of arg.CompetingBuilders
+	Builders int
+	// Sizes is the distribution of the number of txs of the bids
+	Sizes []BidSize
+	// Duration is how long bids are sent
+	Duration time.Duration
+	// Concurrency is the max bids in flight, a bid due when all are in flight is dropped
+	Concurrency int
+	// ReportInterval is the interval of the reports logged during the run, not logged if 0
+	ReportInterval time.Duration
+}
+
+// interval returns the interval between the bids of the rate.
+func (c LoadConfig) interval() time.Duration {
+	return time.Duration(float64(time.Second) / c.Rate)
+}
+
+// validate checks the rate has a positive interval between the bids, and the sizes can be picked.
+func (c LoadConfig) validate() error {
+	if !(c.Rate > 0) || c.interval() <= 0 {
+		return fmt.Errorf("invalid load rate %v", c.Rate)
+	}
+
+	total := 0
+	for _, size := range c.Sizes {
+		if size.Txs <= 0 || size.Weight < 0 {
+			return fmt.Errorf("invalid bid size %v:%v", size.Txs, size.Weight)
+		}
+		total += size.Weight
+	}
+	if total == 0 {
+		return fmt.Errorf("no weight of the %v bid sizes", len(c.Sizes))
+	}
+
+	return nil
+}
+
+// LoadReport is the throughput, latencies and responses of a load run.
+type LoadReport struct {
+	Elapsed time.Duration
+	// Sent is the number of bids sent, Dropped the bids due but not sent as all were in flight
+	Sent, Accepted, Dropped int
+	// Unexpected is the number of responses neither accepted nor an error of BidErrorCodes
+	Unexpected int
+	// Rate is the bids sent per second
+	Rate               float64
+	P50, P90, P99, Max time.Duration
+	// ErrorCodes counts the errors by json-rpc error code, 0 for errors not of json-rpc
+	ErrorCodes map[int]int
+}
+
+func (r *LoadReport) String() string {
+	codes := make([]int, 0, len(r.ErrorCodes))
+	for code := range r.ErrorCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	errs := make([]string, 0, len(codes))
+	for _, code := range codes {
+		errs = append(errs, fmt.Sprintf("%v:%v", code, r.ErrorCodes[code]))
+	}
+
+	return fmt.Sprintf("%v sent in %v (%.1f bids/s), %v accepted, %v dropped, %v unexpected, "+
+		"latency p50 %v p90 %v p99 %v max %v, errors {%v}",
+		r.Sent, r.Elapsed.Round(time.Millisecond), r.Rate, r.Accepted, r.Dropped, r.Unexpected,
+		r.P50, r.P90, r.P99, r.Max, strings.Join(errs, " "))
+}
+
+// loadStats counts the responses of a load run, it is safe for concurrent use.
+type loadStats struct {
+	start   time.Time
+	latency LatencyHistogram
+
+	mu         sync.Mutex
+	sent       int
+	accepted   int
+	dropped    int
+	unexpected int
+	first      error
+	codes      map[int]int
+}
+
+func newLoadStats() *loadStats {
+	return &loadStats{start: time.Now(), codes: make(map[int]int)}
+}
+
+func (s *loadStats) observe(latency time.Duration, err error) {
+	s.latency.Observe(latency)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent++
+	if err == nil {
+		s.accepted++
+		return
+	}
+
+	code, _ := errorCode(err)
+	s.codes[code]++
+
+	if er := CheckBidResponse(err); er != nil {
+		s.unexpected++
+		if s.first == nil {
+			s.first = er
+		}
+	}
+}
+
+func (s *loadStats) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dropped++
+}
+
+func (s *loadStats) report() *LoadReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := &LoadReport{
+		Elapsed:    time.Since(s.start),
+		Sent:       s.sent,
+		Accepted:   s.accepted,
+		Dropped:    s.dropped,
+		Unexpected: s.unexpected,
+		P50:        s.latency.Quantile(0.5),
+		P90:        s.latency.Quantile(0.9),
+		P99:        s.latency.Quantile(0.99),
+		Max:        s.latency.Quantile(1),
+		ErrorCodes: make(map[int]int, len(s.codes)),
+	}
+	r.Rate = float64(r.Sent) / r.Elapsed.Seconds()
+
+	for code, n := range s.codes {
+		r.ErrorCodes[code] = n
+	}
+
+	return r
+}
+
+// loadBuilder is a builder identity of a load run, bidding the txs of its factory.
+type loadBuilder struct {
+	// arg is a copy of the case arg bidding as the builder
+	arg     *BidCaseArg
+	factory *BidFactory
+
+	mu   sync.Mutex
+	head common.Hash
+}
+
+func newLoadBuilders(arg *BidCaseArg, n int) ([]*loadBuilder, error) {
+	if n < 1 || n > len(arg.CompetingBuilders)+1 {
+		return nil, fmt.Errorf("load needs 1 to %v builders, got %v", len(arg.CompetingBuilders)+1, n)
+	}
+
+	builders := []*loadBuilder{{
+		arg:     arg,
+		factory: NewBidFactory(arg.Ctx, arg.FullNode, arg.Nonces, arg.Root, arg.Bob, arg.Abc),
+	}}
+
+	for _, signer := range arg.CompetingBuilders[:n-1] {
+		factory := NewBidFactory(arg.Ctx, arg.FullNode, arg.Nonces, signer, arg.Bob, arg.Abc)
+
+		builderArg := *arg
+		builderArg.Builder = factory.Root()
+		builders = append(builders, &loadBuilder{arg: &builderArg, factory: factory})
+	}
+
+	return builders, nil
+}
+
+// bid generates a bid of size BNB transfers on the head, the bids of the builder for the same
+// block share the nonces of their txs.
+func (b *loadBuilder) bid(head *types.Block, size int, chainID *big.Int) (*types.BidArgs, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if head.Hash() != b.head {
+		b.head = head.Hash()
+		if _, err := b.arg.Nonces.Manager(b.factory.Root().Address).Confirm(b.arg.Ctx); err != nil {
+			log.Errorw("failed to confirm nonces", "err", err)
+		}
+	}
+
+	txs, err := b.factory.BundleBNB(TransferAmountPerTx, size)
+	if err != nil {
+		return nil, err
+	}
+	releaseNonces(b.arg, txs)
+
+	gasUsed := BNBGasUsed * int64(size)
+	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
+	return genValidBidWithBlock(b.arg, txs, gasUsed, gasFee, false, nil, chainID, head)
+}
+
+// headCache reads the head block at most once per headCacheTTL for all the builders.
+type headCache struct {
+	arg *BidCaseArg
+
+	mu    sync.Mutex
+	block *types.Block
+	at    time.Time
+}
+
+func (c *headCache) get() (*types.Block, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.block != nil && time.Since(c.at) < headCacheTTL {
+		return c.block, nil
+	}
+
+	block, err := c.arg.FullNode.BlockByNumber(c.arg.Ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.block, c.at = block, time.Now()
+	return block, nil
+}
+
+// RunLoad sends bids at the rate of the config for its duration, and reports the latencies and
+// responses periodically and at the end. The case fails if no bid is accepted or any response is
+// neither accepted nor an error of BidErrorCodes.
+func RunLoad(arg *BidCaseArg, config LoadConfig) []*CaseResult {
+	// the run is bounded by its duration instead
+	loadArg := *arg
+	loadArg.CaseTimeout = 0
+
+	return []*CaseResult{runCase(&loadArg, &CaseInfo{
+		Name: "Load",
+		Fn: func(arg *BidCaseArg) error {
+			// the report is logged by runLoad
+			_, err := runLoad(arg, config)
+			return err
+		},
+	})}
+}
+
+func runLoad(arg *BidCaseArg, config LoadConfig) (*LoadReport, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	// the bids are sent concurrently, so not recorded to the case result
+	loadArg := *arg
+	loadArg.result = nil
+	if loadArg.Nonces == nil {
+		loadArg.Nonces = NewNonces(arg.FullNode)
+	}
+
+	builders, err := newLoadBuilders(&loadArg, config.Builders)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	chainID := arg.chainID()
+	heads := &headCache{arg: &loadArg}
+	stats := newLoadStats()
+
+	var next atomic.Uint64
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for size := range jobs {
+				b := builders[next.Add(1)%uint64(len(builders))]
+
+				head, err := heads.get()
+				if err != nil {
+					log.Errorw("failed to get head", "err", err)
+					stats.drop()
+					continue
+				}
+
+				bidArgs, err := b.bid(head, size, chainID)
+				if err != nil {
+					log.Errorw("failed to generate bid", "err", err)
+					stats.drop()
+					continue
+				}
+
+				start := time.Now()
+				_, err = b.arg.sendBid(bidArgs)
+				stats.observe(time.Since(start), err)
+			}
+		}()
+	}
+
+	log.Infow("load", "rate", config.Rate, "builders", config.Builders, "duration", config.Duration,
+		"concurrency", concurrency)
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	ticker := time.NewTicker(config.interval())
+	defer ticker.Stop()
+	deadline := time.NewTimer(config.Duration)
+	defer deadline.Stop()
+
+	var reports <-chan time.Time
+	if config.ReportInterval > 0 {
+		reportTicker := time.NewTicker(config.ReportInterval)
+		defer reportTicker.Stop()
+		reports = reportTicker.C
+	}
+
+	var stopErr error
+dispatch:
+	for {
+		select {
+		case <-arg.Ctx.Done():
+			stopErr = arg.Ctx.Err()
+			break dispatch
+		case <-deadline.C:
+			break dispatch
+		case <-reports:
+			log.Infow("load report", "report", stats.report().String())
+		case <-ticker.C:
+			select {
+			case jobs <- pickBidSize(r, config.Sizes):
+			default:
+				stats.drop()
+			}
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	report := stats.report()
+	log.Infow("load finished", "report", report.String())
+
+	switch {
+	case stopErr != nil:
+		return report, fmt.Errorf("load stopped, %w", stopErr)
+	case report.Unexpected > 0:
+		return report, fmt.Errorf("%v of %v bids got unexpected responses, first %v",
+			report.Unexpected, report.Sent, stats.first)
+	case report.Accepted == 0:
+		return report, fmt.Errorf("none of %v bids accepted", report.Sent)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,98 @@
+package cases
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBidSizes(t *testing.T) {
+	sizes, err := ParseBidSizes("8:2, 32,512:1")
+	assert.Nil(t, err)
+	assert.Equal(t, []BidSize{{Txs: 8, Weight: 2}, {Txs: 32, Weight: 1}, {Txs: 512, Weight: 1}}, sizes)
+
+	for _, s := range []string{"", "8:", "0", "8:0", "a:1", "8:-1"} {
+		_, err = ParseBidSizes(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	h := new(LatencyHistogram)
+	assert.Zero(t, h.Quantile(0.5))
+
+	for i := 100; i > 0; i-- {
+		h.Observe(time.Duration(i) * time.Millisecond)
+	}
+
+	assert.Equal(t, 100, h.Count())
+	assert.Equal(t, time.Millisecond, h.Quantile(0))
+	assert.Equal(t, 50*time.Millisecond, h.Quantile(0.5))
+	assert.Equal(t, 99*time.Millisecond, h.Quantile(0.99))
+	assert.Equal(t, 100*time.Millisecond, h.Quantile(1))
+
+	// beyond latencySamples the quantiles are estimated from a sample, the max is exact
+	h = new(LatencyHistogram)
+	for i := 1; i <= 100000; i++ {
+		h.Observe(time.Duration(i) * time.Millisecond)
+	}
+
+	assert.Equal(t, 100000, h.Count())
+	assert.Len(t, h.samples, latencySamples)
+	assert.InDelta(t, float64(50*time.Second), float64(h.Quantile(0.5)), float64(5*time.Second))
+	assert.InDelta(t, float64(90*time.Second), float64(h.Quantile(0.9)), float64(5*time.Second))
+	assert.Equal(t, 100*time.Second, h.Quantile(1))
+}
+
+func TestLoadConfig(t *testing.T) {
+	sizes := []BidSize{{Txs: 8, Weight: 1}}
+	assert.Nil(t, LoadConfig{Rate: 10, Sizes: sizes}.validate())
+
+	for _, c := range []LoadConfig{
+		{Rate: 0, Sizes: sizes},
+		{Rate: -1, Sizes: sizes},
+		{Rate: math.NaN(), Sizes: sizes},
+		{Rate: 1e10, Sizes: sizes},
+		{Rate: 10},
+		{Rate: 10, Sizes: []BidSize{{Txs: 8, Weight: 0}, {Txs: 32, Weight: 0}}},
+		{Rate: 10, Sizes: []BidSize{{Txs: 8, Weight: -1}, {Txs: 32, Weight: 2}}},
+		{Rate: 10, Sizes: []BidSize{{Txs: 0, Weight: 1}}},
+	} {
+		assert.NotNil(t, c.validate(), "%+v", c)
+	}
+
+	arg, _ := newTestArg(t, 0)
+	_, err := runLoad(arg, LoadConfig{Rate: 10, Sizes: []BidSize{{Txs: 8}}, Duration: time.Second})
+	assert.ErrorContains(t, err, "no weight")
+}
+
+func TestRunLoad(t *testing.T) {
+	arg, _ := newTestArg(t, 200*time.Millisecond)
+
+	report, err := runLoad(arg, LoadConfig{
+		Rate:        50,
+		Builders:    3,
+		Sizes:       []BidSize{{Txs: 1, Weight: 1}, {Txs: 8, Weight: 1}},
+		Duration:    time.Second,
+		Concurrency: 4,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, report.Sent, report.Accepted+sumCodes(report))
+	assert.Positive(t, report.Accepted)
+	assert.Zero(t, report.Unexpected)
+	assert.Positive(t, report.P50)
+	assert.LessOrEqual(t, report.P50, report.P99)
+
+	_, err = runLoad(arg, LoadConfig{Rate: 50, Builders: 5, Sizes: []BidSize{{Txs: 1, Weight: 1}}})
+	assert.ErrorContains(t, err, "load needs 1 to 4 builders")
+}
+
+func sumCodes(report *LoadReport) int {
+	n := 0
+	for _, c := range report.ErrorCodes {
+		n += c
+	}
+	return n
+}
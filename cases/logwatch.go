@@ -0,0 +1,376 @@
+package cases
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/bsc-mev-cases/log"
+)
+
+// Messages logged by the validator when a bid fails in simulation, a message may differ between
+// versions of the validator, so each expectation accepts any of its alternatives.
+var (
+	LogInsufficientFunds = []string{"insufficient funds"}
+	LogGasLimitReached   = []string{"gas limit reached", "gas used exceeds gas limit"}
+	LogInvalidReward     = []string{"invalid reward", "reward does not achieve the expectation"}
+
+	// LogBidErrors are all the messages of a bid failed in simulation
+	LogBidErrors = concatLogs(LogInsufficientFunds, LogGasLimitReached, LogInvalidReward)
+)
+
+func concatLogs(msgs ...[]string) []string {
+	all := make([]string, 0)
+	for _, m := range msgs {
+		all = append(all, m...)
+	}
+
+	return all
+}
+
+// DefaultLogWindow is the time waited for a message logged by the validator after a bid is sent.
+const DefaultLogWindow = 10 * time.Second
+
+// maxLogLines is the number of the latest lines kept by LogWatcher.
+const maxLogLines = 10000
+
+// LogMark is a position in the lines read by LogWatcher, e.g. before a bid is sent.
+type LogMark uint64
+
+// LogWatcher reads the log of the validator line by line, so a case can check the validator
+// logs a message after the bid is sent.
+type LogWatcher struct {
+	reader io.Reader
+
+	mu sync.Mutex
+	// first is the position of lines[0]
+	first  uint64
+	lines  []string
+	notify chan struct{}
+	err    error
+}
+
+// NewLogWatcher watches the lines read from the reader, e.g. a pipe from the validator.
+func NewLogWatcher(r io.Reader) *LogWatcher {
+	w := &LogWatcher{
+		reader: r,
+		notify: make(chan struct{}),
+	}
+
+	go w.read()
+	return w
+}
+
+// TailLogFile watches the lines appended to the log file from now on, like tail -F,
+// the file is reopened if it is rotated or truncated.
+func TailLogFile(path string, interval time.Duration) (*LogWatcher, error) {
+	t, err := newFileTailer(path, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLogWatcher(t), nil
+}
+
+// Close stops watching if the reader can be closed.
+func (w *LogWatcher) Close() error {
+	if c, ok := w.reader.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// Mark returns the position after the lines read.
+func (w *LogWatcher) Mark() LogMark {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return LogMark(w.first + uint64(len(w.lines)))
+}
+
+// WaitFor waits at most window for a line after the mark containing any of the messages,
+// case-insensitive, and returns the line.
+func (w *LogWatcher) WaitFor(ctx context.Context, mark LogMark, window time.Duration, msgs ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+
+	lower := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		lower = append(lower, strings.ToLower(msg))
+	}
+
+	for {
+		w.mu.Lock()
+		line, found := w.findLocked(mark, lower)
+		notify, readErr := w.notify, w.err
+		w.mu.Unlock()
+
+		if found {
+			return line, nil
+		}
+
+		if readErr != nil {
+			return "", fmt.Errorf("log ended before any of %q logged, %v", msgs, readErr)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("none of %q logged in %v, %w", msgs, window, ctx.Err())
+		case <-notify:
+		}
+	}
+}
+
+// WaitForNone waits for the window and checks no line after the mark contains any of the
+// messages, case-insensitive, it returns an error of the first line that does.
+func (w *LogWatcher) WaitForNone(ctx context.Context, mark LogMark, window time.Duration, msgs ...string) error {
+	timer := time.NewTimer(window)
+	defer timer.Stop()
+
+	lower := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		lower = append(lower, strings.ToLower(msg))
+	}
+
+	expired := false
+	for {
+		w.mu.Lock()
+		line, found := w.findLocked(mark, lower)
+		notify, readErr := w.notify, w.err
+		w.mu.Unlock()
+
+		if found {
+			return fmt.Errorf("unexpected log in %v, %s", window, line)
+		}
+
+		// no more lines to check
+		if expired || readErr != nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			expired = true
+		case <-notify:
+		}
+	}
+}
+
+func (w *LogWatcher) findLocked(mark LogMark, msgs []string) (string, bool) {
+	start := uint64(mark)
+	if start < w.first {
+		start = w.first
+	}
+
+	for _, line := range w.lines[start-w.first:] {
+		lower := strings.ToLower(line)
+		for _, msg := range msgs {
+			if strings.Contains(lower, msg) {
+				return line, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func (w *LogWatcher) read() {
+	scanner := bufio.NewScanner(w.reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		w.append(scanner.Text())
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		err = io.EOF
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.err = err
+	close(w.notify)
+}
+
+func (w *LogWatcher) append(line string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.lines = append(w.lines, line)
+	if n := len(w.lines) - maxLogLines; n > 0 {
+		w.lines = append(w.lines[:0:0], w.lines[n:]...)
+		w.first += uint64(n)
+	}
+
+	close(w.notify)
+	w.notify = make(chan struct{})
+}
+
+// fileTailer reads the file as it grows, it never returns io.EOF until closed.
+type fileTailer struct {
+	path     string
+	interval time.Duration
+
+	mu     sync.Mutex
+	file   *os.File
+	offset int64
+	closed chan struct{}
+}
+
+func newFileTailer(path string, interval time.Duration) (*fileTailer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &fileTailer{
+		path:     path,
+		interval: interval,
+		file:     f,
+		offset:   offset,
+		closed:   make(chan struct{}),
+	}, nil
+}
+
+func (t *fileTailer) Read(p []byte) (int, error) {
+	for {
+		n, err := t.read(p)
+		if n > 0 || (err != nil && !errors.Is(err, io.EOF)) {
+			return n, err
+		}
+
+		select {
+		case <-t.closed:
+			return 0, io.EOF
+		case <-time.After(t.interval):
+		}
+	}
+}
+
+func (t *fileTailer) read(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.closed:
+		return 0, io.EOF
+	default:
+	}
+
+	n, err := t.file.Read(p)
+	t.offset += int64(n)
+	if n > 0 || !errors.Is(err, io.EOF) {
+		return n, err
+	}
+
+	t.reopenLocked()
+	return 0, io.EOF
+}
+
+// reopenLocked reads the file from the start if it is rotated or truncated.
+func (t *fileTailer) reopenLocked() {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		// rotated, the new file is not created yet
+		return
+	}
+
+	current, err := t.file.Stat()
+	if err != nil {
+		return
+	}
+
+	if !os.SameFile(info, current) {
+		f, err := os.Open(t.path)
+		if err != nil {
+			return
+		}
+
+		t.file.Close()
+		t.file, t.offset = f, 0
+		log.Infow("validator log rotated", "path", t.path)
+		return
+	}
+
+	if info.Size() < t.offset {
+		if _, err = t.file.Seek(0, io.SeekStart); err == nil {
+			t.offset = 0
+			log.Infow("validator log truncated", "path", t.path)
+		}
+	}
+}
+
+func (t *fileTailer) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.closed:
+		return nil
+	default:
+	}
+
+	close(t.closed)
+	return t.file.Close()
+}
+
+// logMark marks the log of the validator before a bid is sent.
+func (arg *BidCaseArg) logMark() LogMark {
+	if arg.Logs == nil {
+		return 0
+	}
+
+	return arg.Logs.Mark()
+}
+
+// expectLog checks the validator logs any of the messages after the mark in LogWindow,
+// it is skipped if the log of the validator is not watched.
+func (arg *BidCaseArg) expectLog(mark LogMark, msgs []string) error {
+	if arg.Logs == nil {
+		return nil
+	}
+
+	window := arg.LogWindow
+	if window == 0 {
+		window = DefaultLogWindow
+	}
+
+	line, err := arg.Logs.WaitFor(arg.Ctx, mark, window, msgs...)
+	if err != nil {
+		return err
+	}
+
+	log.Infow("validator logged", "line", line)
+	return nil
+}
+
+// expectNoLog checks the validator logs none of the messages after the mark in LogWindow,
+// it is skipped if the log of the validator is not watched.
+func (arg *BidCaseArg) expectNoLog(mark LogMark, msgs []string) error {
+	if arg.Logs == nil {
+		return nil
+	}
+
+	window := arg.LogWindow
+	if window == 0 {
+		window = DefaultLogWindow
+	}
+
+	return arg.Logs.WaitForNone(arg.Ctx, mark, window, msgs...)
+}
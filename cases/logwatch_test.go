@@ -0,0 +1,110 @@
+package cases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeValidatorLog appends the lines to the log file after the delay, as a validator does.
+func fakeValidatorLog(t *testing.T, path string, delay time.Duration, lines ...string) {
+	go func() {
+		time.Sleep(delay)
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer f.Close()
+
+		for _, line := range lines {
+			fmt.Fprintln(f, line)
+		}
+	}()
+}
+
+func TestTailLogFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "bsc.log")
+	assert.Nil(t, os.WriteFile(path, []byte("t=old lvl=dbug msg=\"bid simulation failed\" err=\"invalid reward\"\n"), 0o644))
+
+	logs, err := TailLogFile(path, 10*time.Millisecond)
+	assert.Nil(t, err)
+	defer logs.Close()
+
+	// lines before tailing are not watched
+	mark := logs.Mark()
+	_, err = logs.WaitFor(ctx, mark, 100*time.Millisecond, LogInvalidReward...)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	fakeValidatorLog(t, path, 50*time.Millisecond,
+		"t=new lvl=info msg=\"Imported new chain segment\"",
+		"t=new lvl=dbug msg=\"bid simulation failed\" err=\"invalid tx in bid, Insufficient Funds for gas * price + value\"",
+	)
+	line, err := logs.WaitFor(ctx, mark, time.Second, LogInsufficientFunds...)
+	assert.Nil(t, err)
+	assert.Contains(t, line, "Insufficient Funds")
+
+	// lines before the mark are not matched
+	mark = logs.Mark()
+	_, err = logs.WaitFor(ctx, mark, 100*time.Millisecond, LogInsufficientFunds...)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// truncated
+	assert.Nil(t, os.Truncate(path, 0))
+	fakeValidatorLog(t, path, 50*time.Millisecond, "msg=\"bid simulation failed\" err=\"gas used exceeds gas limit\"")
+	_, err = logs.WaitFor(ctx, mark, time.Second, LogGasLimitReached...)
+	assert.Nil(t, err)
+
+	// rotated
+	mark = logs.Mark()
+	assert.Nil(t, os.Rename(path, path+".1"))
+	fakeValidatorLog(t, path, 50*time.Millisecond, "err=\"reward does not achieve the expectation\"")
+	_, err = logs.WaitFor(ctx, mark, time.Second, LogInvalidReward...)
+	assert.Nil(t, err)
+
+	assert.Nil(t, logs.Close())
+	_, err = logs.WaitFor(ctx, logs.Mark(), time.Second, LogInvalidReward...)
+	assert.ErrorContains(t, err, "log ended")
+}
+
+func TestExpectLog(t *testing.T) {
+	arg := &BidCaseArg{Ctx: context.Background(), LogWindow: 100 * time.Millisecond}
+	assert.Nil(t, arg.expectLog(arg.logMark(), LogInvalidReward))
+
+	r, w := io.Pipe()
+	arg.Logs = NewLogWatcher(r)
+	defer w.Close()
+
+	mark := arg.logMark()
+	go fmt.Fprintln(w, "err=\"invalid reward, expected 1, got 0\"")
+	assert.Nil(t, arg.expectLog(mark, LogInvalidReward))
+	assert.NotNil(t, arg.expectLog(mark, LogGasLimitReached))
+}
+
+func TestExpectNoLog(t *testing.T) {
+	arg := &BidCaseArg{Ctx: context.Background(), LogWindow: 100 * time.Millisecond}
+	assert.Nil(t, arg.expectNoLog(arg.logMark(), LogBidErrors))
+
+	r, w := io.Pipe()
+	arg.Logs = NewLogWatcher(r)
+	defer w.Close()
+
+	mark := arg.logMark()
+	go fmt.Fprintln(w, "msg=\"bid simulated\" gasUsed=420000")
+	assert.Nil(t, arg.expectNoLog(mark, LogBidErrors))
+
+	mark = arg.logMark()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprintln(w, "err=\"gas limit reached\"")
+	}()
+	assert.ErrorContains(t, arg.expectNoLog(mark, LogBidErrors), "gas limit reached")
+}
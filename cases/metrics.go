@@ -0,0 +1,327 @@
+package cases
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics collects the cases, bids, receipt waits and BNB spent of a run, exported to Prometheus
+// by Handler and summarized by Summary. It is safe for concurrent use, a nil *Metrics collects
+// nothing.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	cases       *prometheus.CounterVec
+	bids        *prometheus.CounterVec
+	bidErrors   *prometheus.CounterVec
+	bidLatency  prometheus.Histogram
+	waits       *prometheus.CounterVec
+	receiptWait prometheus.Histogram
+	inclusion   prometheus.Gauge
+	spent       prometheus.Counter
+
+	// the quantiles of Summary, of a bounded sample of the latencies
+	bidLatencies LatencyHistogram
+	receiptWaits LatencyHistogram
+
+	mu       sync.Mutex
+	byCase   map[string]*CaseSummary
+	codes    map[int]int
+	waited   int
+	included int
+	spentWei *big.Int
+}
+
+// NewMetrics creates the metrics on a registry of their own.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		cases: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bidbot_cases_total",
+			Help: "Cases run, by case and status.",
+		}, []string{"case", "status"}),
+		bids: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bidbot_bids_total",
+			Help: "Bids sent, by case.",
+		}, []string{"case"}),
+		bidErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bidbot_bid_errors_total",
+			Help: "Bids rejected, by case and json-rpc error code, 0 for errors not of json-rpc.",
+		}, []string{"case", "code"}),
+		bidLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "bidbot_bid_latency_seconds",
+			Help:    "Latency of mev_sendBid.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+		}),
+		waits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bidbot_bid_waits_total",
+			Help: "Bids waited for their block, by case and result, included or missed.",
+		}, []string{"case", "result"}),
+		receiptWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "bidbot_receipt_wait_seconds",
+			Help:    "Time waited for the block and receipts of a bid.",
+			Buckets: prometheus.ExponentialBuckets(0.5, 2, 10),
+		}),
+		inclusion: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "bidbot_inclusion_ratio",
+			Help: "Ratio of the bids waited which are included in their block.",
+		}),
+		spent: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bidbot_bnb_spent_total",
+			Help: "BNB spent on the gas fee of the txs and the builder fee of the bids included.",
+		}),
+
+		byCase:   make(map[string]*CaseSummary),
+		codes:    make(map[int]int),
+		spentWei: new(big.Int),
+	}
+
+	m.registry.MustRegister(m.cases, m.bids, m.bidErrors, m.bidLatency, m.waits, m.receiptWait,
+		m.inclusion, m.spent)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// caseSummaryLocked returns the summary of the case, created if absent.
+func (m *Metrics) caseSummaryLocked(name string) *CaseSummary {
+	s, ok := m.byCase[name]
+	if !ok {
+		s = &CaseSummary{ErrorCodes: make(map[int]int)}
+		m.byCase[name] = s
+	}
+
+	return s
+}
+
+// observeCase counts the result of a case.
+func (m *Metrics) observeCase(res *CaseResult) {
+	if m == nil {
+		return
+	}
+
+	m.cases.WithLabelValues(res.Name, string(res.Status)).Inc()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.caseSummaryLocked(res.Name)
+	s.Runs++
+	if res.Failed() {
+		s.Failed++
+	} else {
+		s.Passed++
+	}
+}
+
+// observeBid counts a bid sent by the case and its response.
+func (m *Metrics) observeBid(name string, latency time.Duration, err error) {
+	if m == nil {
+		return
+	}
+
+	m.bids.WithLabelValues(name).Inc()
+	m.bidLatency.Observe(latency.Seconds())
+	m.bidLatencies.Observe(latency)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.caseSummaryLocked(name)
+	s.Bids++
+	if err == nil {
+		return
+	}
+
+	code, _ := errorCode(err)
+	m.bidErrors.WithLabelValues(name, strconv.Itoa(code)).Inc()
+	s.ErrorCodes[code]++
+	m.codes[code]++
+}
+
+// observeWait counts a bid waited for its block, the receipts are of the bid included.
+func (m *Metrics) observeWait(name string, wait time.Duration, bidArgs *types.BidArgs, receipts []*types.Receipt,
+	err error) {
+	if m == nil {
+		return
+	}
+
+	m.receiptWait.Observe(wait.Seconds())
+	m.receiptWaits.Observe(wait)
+
+	result := "included"
+	if err != nil {
+		result = "missed"
+	}
+	m.waits.WithLabelValues(name, result).Inc()
+
+	spent := new(big.Int)
+	if err == nil {
+		spent = bidSpent(bidArgs, receipts)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.waited++
+	if err == nil {
+		m.included++
+	}
+	m.inclusion.Set(float64(m.included) / float64(m.waited))
+
+	m.spentWei.Add(m.spentWei, spent)
+	bnb, _ := weiToBNB(spent).Float64()
+	m.spent.Add(bnb)
+}
+
+// bidSpent returns the gas fee of the receipts and the builder fee paid by the PayBidTx of the bid.
+func bidSpent(bidArgs *types.BidArgs, receipts []*types.Receipt) *big.Int {
+	spent := new(big.Int)
+	for _, receipt := range receipts {
+		if receipt.EffectiveGasPrice != nil {
+			spent.Add(spent, new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed)))
+		}
+	}
+
+	if len(bidArgs.PayBidTx) != 0 {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(bidArgs.PayBidTx); err == nil {
+			spent.Add(spent, tx.Value())
+		}
+	}
+
+	return spent
+}
+
+func weiToBNB(wei *big.Int) *big.Float {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18))
+}
+
+// CaseSummary is the counters of a case in MetricsSummary.
+type CaseSummary struct {
+	Runs   int `json:"runs"`
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+	Bids   int `json:"bids"`
+	// ErrorCodes counts the bids rejected by json-rpc error code, 0 for errors not of json-rpc
+	ErrorCodes map[int]int `json:"errorCodes,omitempty"`
+}
+
+// LatencySummary is the quantiles of latencies in milliseconds.
+type LatencySummary struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50Ms"`
+	P90   float64 `json:"p90Ms"`
+	P99   float64 `json:"p99Ms"`
+	Max   float64 `json:"maxMs"`
+}
+
+func newLatencySummary(h *LatencyHistogram) LatencySummary {
+	ms := func(q float64) float64 {
+		return float64(h.Quantile(q)) / float64(time.Millisecond)
+	}
+
+	return LatencySummary{Count: h.Count(), P50: ms(0.5), P90: ms(0.9), P99: ms(0.99), Max: ms(1)}
+}
+
+func (s LatencySummary) String() string {
+	return fmt.Sprintf("%v, p50 %.1fms p90 %.1fms p99 %.1fms max %.1fms", s.Count, s.P50, s.P90, s.P99, s.Max)
+}
+
+// MetricsSummary is the final summary of the metrics of a run.
+type MetricsSummary struct {
+	Cases       map[string]*CaseSummary `json:"cases"`
+	ErrorCodes  map[int]int             `json:"errorCodes"`
+	BidLatency  LatencySummary          `json:"bidLatency"`
+	ReceiptWait LatencySummary          `json:"receiptWait"`
+	// Waited is the number of bids waited for their block, Included of them are included
+	Waited        int     `json:"waited"`
+	Included      int     `json:"included"`
+	InclusionRate float64 `json:"inclusionRate"`
+	SpentWei      string  `json:"spentWei"`
+	SpentBNB      float64 `json:"spentBNB"`
+}
+
+// Summary returns the summary of the metrics collected so far.
+func (m *Metrics) Summary() *MetricsSummary {
+	s := &MetricsSummary{
+		Cases:       make(map[string]*CaseSummary),
+		ErrorCodes:  make(map[int]int),
+		BidLatency:  newLatencySummary(&m.bidLatencies),
+		ReceiptWait: newLatencySummary(&m.receiptWaits),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, c := range m.byCase {
+		copied := *c
+		copied.ErrorCodes = make(map[int]int, len(c.ErrorCodes))
+		for code, n := range c.ErrorCodes {
+			copied.ErrorCodes[code] = n
+		}
+		s.Cases[name] = &copied
+	}
+
+	for code, n := range m.codes {
+		s.ErrorCodes[code] = n
+	}
+
+	s.Waited, s.Included = m.waited, m.included
+	if m.waited > 0 {
+		s.InclusionRate = float64(m.included) / float64(m.waited)
+	}
+
+	s.SpentWei = m.spentWei.String()
+	s.SpentBNB, _ = weiToBNB(m.spentWei).Float64()
+	return s
+}
+
+// WriteJSON writes the summary as indented JSON.
+func (s *MetricsSummary) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+func (s *MetricsSummary) String() string {
+	var b strings.Builder
+
+	names := make([]string, 0, len(s.Cases))
+	for name := range s.Cases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		c := s.Cases[name]
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(&b, "%-48s runs %v passed %v failed %v bids %v errors %v\n",
+			name, c.Runs, c.Passed, c.Failed, c.Bids, c.ErrorCodes)
+	}
+
+	fmt.Fprintf(&b, "bid latency: %v\n", s.BidLatency)
+	fmt.Fprintf(&b, "receipt wait: %v\n", s.ReceiptWait)
+	fmt.Fprintf(&b, "errors: %v\n", s.ErrorCodes)
+	fmt.Fprintf(&b, "inclusion: %v of %v (%.2f%%)\n", s.Included, s.Waited, s.InclusionRate*100)
+	fmt.Fprintf(&b, "spent: %v BNB\n", s.SpentBNB)
+	return b.String()
+}
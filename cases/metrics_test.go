@@ -0,0 +1,69 @@
+package cases
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	if testing.Short() {
+		t.Skip("valid cases wait for receipts")
+	}
+
+	arg, _ := newTestArg(t, 500*time.Millisecond)
+	arg.Metrics = NewMetrics()
+
+	for _, name := range []string{"ValidBid_PayBidTx_200", "InvalidBid_OldBlockNumber_20"} {
+		c, err := Lookup(name)
+		assert.Nil(t, err)
+
+		res := runCaseFn(arg, c.Name, c.Fn)
+		assert.Equal(t, StatusPassed, res.Status, "%s: %s", res.Name, res.Error)
+	}
+
+	summary := arg.Metrics.Summary()
+	valid := summary.Cases["ValidBid_PayBidTx_200"]
+	assert.Equal(t, 1, valid.Runs)
+	assert.Equal(t, 1, valid.Passed)
+	assert.Positive(t, valid.Bids)
+
+	invalid := summary.Cases["InvalidBid_OldBlockNumber_20"]
+	assert.Equal(t, 1, invalid.Passed)
+	assert.Equal(t, invalid.Bids, invalid.ErrorCodes[types.InvalidBidParamError])
+	assert.Equal(t, invalid.Bids, summary.ErrorCodes[types.InvalidBidParamError])
+
+	assert.Equal(t, valid.Bids+invalid.Bids, summary.BidLatency.Count)
+	assert.Equal(t, 1, summary.Included)
+	assert.Equal(t, 1, summary.Waited)
+	assert.Equal(t, 1.0, summary.InclusionRate)
+	assert.Equal(t, 1, summary.ReceiptWait.Count)
+	// the gas fee of the txs and the builder fee
+	assert.Greater(t, summary.SpentBNB, 0.42+0.0005-1e-9)
+
+	server := httptest.NewServer(arg.Metrics.Handler())
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	for _, line := range []string{
+		`bidbot_cases_total{case="ValidBid_PayBidTx_200",status="passed"} 1`,
+		`bidbot_bid_errors_total{case="InvalidBid_OldBlockNumber_20",code="-38001"}`,
+		`bidbot_bid_waits_total{case="ValidBid_PayBidTx_200",result="included"} 1`,
+		`bidbot_inclusion_ratio 1`,
+		`bidbot_receipt_wait_seconds_count 1`,
+	} {
+		assert.True(t, strings.Contains(string(body), line), line)
+	}
+
+	assert.Contains(t, summary.String(), "inclusion: 1 of 1 (100.00%)")
+}
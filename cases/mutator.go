@@ -0,0 +1,174 @@
+package cases
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BidMutator mutates a bid, e.g. to turn a valid bid into an invalid one. Mutators are applied to
+// a copy of the bid by MutateBid, a mutated bid is not signed again unless Resign is applied.
+type BidMutator func(bidArgs *types.BidArgs) error
+
+// MutateBid returns a copy of the bid applied the mutators in order, the bid is left untouched.
+// It stops at the first mutator failing.
+func MutateBid(bidArgs *types.BidArgs, mutators ...BidMutator) (*types.BidArgs, error) {
+	mutated := *bidArgs
+	if raw := bidArgs.RawBid; raw != nil {
+		// not a copy of the struct, the hash cached by RawBid.Hash is stale once mutated
+		mutated.RawBid = &types.RawBid{
+			BlockNumber: raw.BlockNumber,
+			ParentHash:  raw.ParentHash,
+			Txs:         append([]hexutil.Bytes{}, raw.Txs...),
+			GasUsed:     raw.GasUsed,
+			GasFee:      raw.GasFee,
+			BuilderFee:  raw.BuilderFee,
+		}
+	}
+
+	for _, mutate := range mutators {
+		if err := mutate(&mutated); err != nil {
+			return nil, err
+		}
+	}
+
+	return &mutated, nil
+}
+
+// WithBlockNumber sets the block number of the bid.
+func WithBlockNumber(number uint64) BidMutator {
+	return func(bidArgs *types.BidArgs) error {
+		bidArgs.RawBid.BlockNumber = number
+		return nil
+	}
+}
+
+// WithBlockOffset moves the block number of the bid by offset, e.g. -10 for a stale block.
+// The block number stops at 0.
+func WithBlockOffset(offset int64) BidMutator {
+	return func(bidArgs *types.BidArgs) error {
+		number := bidArgs.RawBid.BlockNumber
+		if offset < 0 && uint64(-offset) > number {
+			bidArgs.RawBid.BlockNumber = 0
+			return nil
+		}
+
+		bidArgs.RawBid.BlockNumber = number + uint64(offset)
+		return nil
+	}
+}
+
+// WithParentHash sets the parent hash of the bid.
+func WithParentHash(hash common.Hash) BidMutator {
+	return func(bidArgs *types.BidArgs) error {
+		bidArgs.RawBid.ParentHash = hash
+		return nil
+	}
+}
+
+// WithGasUsed sets the gasUsed claimed by the bid.
+func WithGasUsed(gasUsed uint64) BidMutator {
+	return func(bidArgs *types.BidArgs) error {
+		bidArgs.RawBid.GasUsed = gasUsed
+		return nil
+	}
+}
+
+// WithGasFee sets the gasFee claimed by the bid, nil is allowed.
+func WithGasFee(gasFee *big.Int) BidMutator {
+	return func(bidArgs *types.BidArgs) error {
+		bidArgs.RawBid.GasFee = gasFee
+		return nil
+	}
+}
+
+// ScaleGasFee multiplies the gasFee claimed by the bid by factor, e.g. big.NewRat(1, 100).
+func ScaleGasFee(factor *big.Rat) BidMutator {
+	return func(bidArgs *types.BidArgs) error {
+		if bidArgs.RawBid.GasFee == nil {
+			return nil
+		}
+
+		gasFee := new(big.Int).Mul(bidArgs.RawBid.GasFee, factor.Num())
+		bidArgs.RawBid.GasFee = gasFee.Quo(gasFee, factor.Denom())
+		return nil
+	}
+}
+
+// WithBuilderFee sets the builderFee of the bid, PayBidTx is left as it is.
+func WithBuilderFee(builderFee *big.Int) BidMutator {
+	return func(bidArgs *types.BidArgs) error {
+		bidArgs.RawBid.BuilderFee = builderFee
+		return nil
+	}
+}
+
+// WithTxs sets the txs of the bid, which may not be decodable.
+func WithTxs(txs []hexutil.Bytes) BidMutator {
+	return func(bidArgs *types.BidArgs) error {
+		bidArgs.RawBid.Txs = txs
+		return nil
+	}
+}
+
+// WithSignature sets the signature of the bid.
+func WithSignature(sig []byte) BidMutator {
+	return func(bidArgs *types.BidArgs) error {
+		bidArgs.Signature = sig
+		return nil
+	}
+}
+
+// CorruptSignature replaces the signature of the bid with bytes which are not a signature.
+func CorruptSignature() BidMutator {
+	return WithSignature([]byte("invalid signature"))
+}
+
+// DropPayBidTx removes PayBidTx from the bid, PayBidTxGasUsed is kept.
+func DropPayBidTx() BidMutator {
+	return WithPayBidTx(nil)
+}
+
+// WithPayBidTx sets PayBidTx of the bid.
+func WithPayBidTx(payBidTx []byte) BidMutator {
+	return func(bidArgs *types.BidArgs) error {
+		bidArgs.PayBidTx = payBidTx
+		return nil
+	}
+}
+
+// WithPayBidTxGasUsed sets PayBidTxGasUsed of the bid.
+func WithPayBidTxGasUsed(gasUsed uint64) BidMutator {
+	return func(bidArgs *types.BidArgs) error {
+		bidArgs.PayBidTxGasUsed = gasUsed
+		return nil
+	}
+}
+
+// Resign signs the mutated raw bid by the builder, so the bid is rejected only for the mutations
+// before it. Mutators after it invalidate the signature again.
+func Resign(builder *Account) BidMutator {
+	return func(bidArgs *types.BidArgs) error {
+		signed, err := builder.SignBid(bidArgs.RawBid)
+		if err != nil {
+			return fmt.Errorf("failed to resign bid, %v", err)
+		}
+		bidArgs.Signature = signed.Signature
+		return nil
+	}
+}
+
+// generateMutatedBid generates a valid bid on the latest block and mutates it, a case retries
+// by calling it again with the same mutators.
+func generateMutatedBid(arg *BidCaseArg, txs []*types.Transaction, gasUsed int64, gasFee *big.Int, payBuilder bool,
+	builderFee *big.Int, mutators ...BidMutator) (*types.BidArgs, error) {
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, payBuilder, builderFee)
+	if err != nil {
+		return nil, err
+	}
+
+	return MutateBid(bidArgs, mutators...)
+}
@@ -0,0 +1,78 @@
+package cases
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutateBid(t *testing.T) {
+	builderKey, builder := newTestKey()
+	account := newAccount(builderKey, nil)
+
+	bidArgs, err := account.SignBid(&types.RawBid{
+		BlockNumber: 100,
+		ParentHash:  common.HexToHash("0x01"),
+		Txs:         []hexutil.Bytes{{0x01}},
+		GasUsed:     21000,
+		GasFee:      big.NewInt(2100),
+	})
+	assert.Nil(t, err)
+	bidArgs.PayBidTx = []byte{0x02}
+	bidArgs.PayBidTxGasUsed = uint64(PayBidGasUsed)
+
+	mutated, err := MutateBid(bidArgs,
+		WithBlockOffset(-10),
+		WithParentHash(common.Hash{}),
+		ScaleGasFee(big.NewRat(1, 100)),
+		DropPayBidTx(),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(90), mutated.RawBid.BlockNumber)
+	assert.Equal(t, common.Hash{}, mutated.RawBid.ParentHash)
+	assert.Equal(t, big.NewInt(21), mutated.RawBid.GasFee)
+	assert.Nil(t, mutated.PayBidTx)
+	assert.Equal(t, uint64(PayBidGasUsed), mutated.PayBidTxGasUsed)
+	// not signed again
+	assert.NotNil(t, VerifyBidSignature(mutated, builder))
+	// the hash is not the one cached by the bid mutated
+	assert.NotEqual(t, bidArgs.RawBid.Hash(), mutated.RawBid.Hash())
+
+	// the bid mutated is untouched
+	assert.Equal(t, uint64(100), bidArgs.RawBid.BlockNumber)
+	assert.Equal(t, common.HexToHash("0x01"), bidArgs.RawBid.ParentHash)
+	assert.Equal(t, big.NewInt(2100), bidArgs.RawBid.GasFee)
+	assert.Equal(t, hexutil.Bytes{0x02}, bidArgs.PayBidTx)
+	assert.Nil(t, VerifyBidSignature(bidArgs, builder))
+
+	resigned, err := MutateBid(bidArgs, WithBlockNumber(0), WithGasFee(nil), Resign(account))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), resigned.RawBid.BlockNumber)
+	assert.Nil(t, resigned.RawBid.GasFee)
+	assert.Nil(t, VerifyBidSignature(resigned, builder))
+
+	corrupted, err := MutateBid(bidArgs, WithGasUsed(0), Resign(account), CorruptSignature(), WithPayBidTxGasUsed(0))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), corrupted.RawBid.GasUsed)
+	assert.Equal(t, uint64(0), corrupted.PayBidTxGasUsed)
+	assert.Equal(t, hexutil.Bytes("invalid signature"), corrupted.Signature)
+	assert.NotNil(t, VerifyBidSignature(corrupted, builder))
+
+	// the block number stops at 0
+	genesis, err := MutateBid(bidArgs, WithBlockOffset(-101))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), genesis.RawBid.BlockNumber)
+	genesis, err = MutateBid(bidArgs, WithBlockOffset(math.MinInt64))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), genesis.RawBid.BlockNumber)
+
+	// a failing mutator fails the mutation
+	_, err = MutateBid(bidArgs, WithGasUsed(0), func(*types.BidArgs) error { return errors.New("boom") })
+	assert.EqualError(t, err, "boom")
+}
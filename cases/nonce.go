@@ -0,0 +1,282 @@
+package cases
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/multierr"
+)
+
+// NonceManager allocates the nonces of an account. Txs of bids are not in the tx pool,
+// so the pending nonce of the full node is behind until the bid is sealed, the manager
+// tracks the nonces in flight instead.
+type NonceManager struct {
+	client  *ethclient.Client
+	address common.Address
+
+	mu       sync.Mutex
+	synced   bool
+	next     uint64
+	inFlight map[uint64]struct{}
+	// target is the block number of the latest bid sent with the nonces in flight
+	target uint64
+}
+
+func newNonceManager(client *ethclient.Client, address common.Address) *NonceManager {
+	return &NonceManager{
+		client:   client,
+		address:  address,
+		inFlight: make(map[uint64]struct{}),
+	}
+}
+
+// Allocate reserves n contiguous nonces and returns the first.
+func (m *NonceManager) Allocate(ctx context.Context, n int) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.syncLocked(ctx); err != nil {
+		return 0, err
+	}
+
+	first := m.next
+	for i := 0; i < n; i++ {
+		m.inFlight[m.next] = struct{}{}
+		m.next++
+	}
+
+	return first, nil
+}
+
+// Release gives back the n nonces from first if they are the latest allocated, so
+// the next allocation reuses them, e.g. competing bids of the same block.
+func (m *NonceManager) Release(first uint64, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if first+uint64(n) != m.next {
+		return
+	}
+
+	for i := 0; i < n; i++ {
+		delete(m.inFlight, first+uint64(i))
+	}
+	m.next = first
+}
+
+// Current returns the next nonce without allocating it, for the tx only one of
+// the bids can include, e.g. PayBidTx of competing bids.
+func (m *NonceManager) Current(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.syncLocked(ctx); err != nil {
+		return 0, err
+	}
+
+	return m.next, nil
+}
+
+// InFlight returns the number of nonces allocated and not confirmed.
+func (m *NonceManager) InFlight() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.inFlight)
+}
+
+// Sent records the block number of a bid sent with the nonces in flight, they can not
+// be resynced until the block is sealed.
+func (m *NonceManager) Sent(number uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if number > m.target {
+		m.target = number
+	}
+}
+
+// Confirm drops the nonces in flight which are used on chain, and moves the next
+// nonce forward if txs are sent by others. It returns the number of the latest block.
+func (m *NonceManager) Confirm(ctx context.Context) (uint64, error) {
+	header, err := m.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	number := header.Number.Uint64()
+	nonce, err := m.client.NonceAt(ctx, m.address, header.Number)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for n := range m.inFlight {
+		if n < nonce {
+			delete(m.inFlight, n)
+		}
+	}
+
+	if !m.synced || m.next < nonce {
+		m.next = nonce
+		m.synced = true
+	}
+
+	return number, nil
+}
+
+// expired reports whether the block of the latest bid sent with the nonces in flight
+// is sealed by the block of the number, so the nonces still in flight are never used.
+func (m *NonceManager) expired(number uint64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.inFlight) > 0 && m.target <= number
+}
+
+// Resync drops the nonces in flight and restarts from the pending nonce of the full node,
+// it is called when the txs in flight are known to be never included.
+func (m *NonceManager) Resync(ctx context.Context) error {
+	nonce, err := m.client.PendingNonceAt(ctx, m.address)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inFlight = make(map[uint64]struct{})
+	m.next = nonce
+	m.synced = true
+	m.target = 0
+	return nil
+}
+
+func (m *NonceManager) syncLocked(ctx context.Context) error {
+	if m.synced {
+		return nil
+	}
+
+	nonce, err := m.client.PendingNonceAt(ctx, m.address)
+	if err != nil {
+		return err
+	}
+
+	m.next = nonce
+	m.synced = true
+	return nil
+}
+
+// Nonces holds the nonce managers of the accounts, shared by all the cases of a run.
+type Nonces struct {
+	client *ethclient.Client
+
+	mu       sync.Mutex
+	managers map[common.Address]*NonceManager
+}
+
+// NewNonces creates the nonce managers reading nonces from the full node.
+func NewNonces(client *ethclient.Client) *Nonces {
+	return &Nonces{
+		client:   client,
+		managers: make(map[common.Address]*NonceManager),
+	}
+}
+
+// Manager returns the nonce manager of the address.
+func (n *Nonces) Manager(address common.Address) *NonceManager {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	m, ok := n.managers[address]
+	if !ok {
+		m = newNonceManager(n.client, address)
+		n.managers[address] = m
+	}
+
+	return m
+}
+
+// Settle is called after a case, txs still in flight after the blocks of their bids are
+// not included by the validator, so the accounts of them are resynced. The accounts of
+// bids for blocks not sealed yet are left alone.
+func (n *Nonces) Settle(ctx context.Context) error {
+	var errs error
+	for _, m := range n.all() {
+		number, err := m.Confirm(ctx)
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+
+		if m.expired(number) {
+			errs = multierr.Append(errs, m.Resync(ctx))
+		}
+	}
+
+	return errs
+}
+
+func (n *Nonces) all() []*NonceManager {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	managers := make([]*NonceManager, 0, len(n.managers))
+	for _, m := range n.managers {
+		managers = append(managers, m)
+	}
+
+	return managers
+}
+
+// IsNonceError reports whether the error is caused by a used or skipped nonce.
+func IsNonceError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "nonce too high")
+}
+
+var txHashPattern = regexp.MustCompile(`0x[0-9a-fA-F]{64}`)
+
+// rejectedSenders returns the senders of the tx the error of the bid names, or of all
+// the txs of the bid if the error names none of them.
+func rejectedSenders(txs types.Transactions, err error) []common.Address {
+	if hash := txHashPattern.FindString(err.Error()); hash != "" {
+		for _, tx := range txs {
+			if tx.Hash() == common.HexToHash(hash) {
+				return txSenders(types.Transactions{tx})
+			}
+		}
+	}
+
+	return txSenders(txs)
+}
+
+// txSenders returns the distinct senders of the txs in order.
+func txSenders(txs types.Transactions) []common.Address {
+	senders := make([]common.Address, 0, len(txs))
+	seen := make(map[common.Address]struct{}, len(txs))
+	for _, tx := range txs {
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			continue
+		}
+
+		if _, ok := seen[from]; !ok {
+			seen[from] = struct{}{}
+			senders = append(senders, from)
+		}
+	}
+
+	return senders
+}
@@ -0,0 +1,93 @@
+package cases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/bsc-mev-cases/mevtest"
+)
+
+func TestNonceManager(t *testing.T) {
+	arg, server := newTestArg(t, 0)
+	ctx := context.Background()
+	root := arg.Root.Address()
+	m := arg.Nonces.Manager(root)
+
+	first, err := m.Allocate(ctx, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), first)
+	assert.Equal(t, 3, m.InFlight())
+
+	m.Release(first, 3)
+	assert.Equal(t, 0, m.InFlight())
+
+	// txs of the competing bid reuse the released nonces
+	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 2)
+	assert.Equal(t, uint64(0), txs[0].Nonce())
+	assert.Equal(t, uint64(1), txs[1].Nonce())
+	assert.Equal(t, 2, m.InFlight())
+
+	// shared by the following txs
+	txs = GenerateBNBTxs(arg, TransferAmountPerTx, 2)
+	assert.Equal(t, uint64(2), txs[0].Nonce())
+
+	// none of them are sealed, so the next case starts from the chain again
+	assert.Nil(t, arg.Nonces.Settle(ctx))
+	assert.Equal(t, 0, m.InFlight())
+	nonce, err := m.Current(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), nonce)
+
+	txs = GenerateBNBTxs(arg, TransferAmountPerTx, 2)
+	gasFee := BNBGasUsed * 2 * DefaultBNBGasPrice.Int64()
+	bidArgs, err := generateValidBid(arg, txs, BNBGasUsed*2, big.NewInt(gasFee), false, nil)
+	assert.Nil(t, err)
+	_, err = arg.sendBid(bidArgs)
+	assert.Nil(t, err)
+
+	// the block of the bid is not sealed yet, its nonces are kept in flight
+	assert.Nil(t, arg.Nonces.Settle(ctx))
+	assert.Equal(t, 2, m.InFlight())
+
+	_, err = server.Chain().Seal()
+	assert.Nil(t, err)
+
+	assert.Nil(t, arg.Nonces.Settle(ctx))
+	assert.Equal(t, 0, m.InFlight())
+	nonce, err = m.Current(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), nonce)
+}
+
+func TestRejectedSenders(t *testing.T) {
+	arg, _ := newTestArg(t, 0)
+	chainID := mevtest.DefaultChainID
+
+	var txs types.Transactions
+	for i, signer := range []Signer{arg.Root, arg.Bob} {
+		tx, err := newAccount(signer, nil).TransferBNB(uint64(i), arg.Bob.Address(), chainID, TransferAmountPerTx)
+		assert.Nil(t, err)
+		txs = append(txs, tx)
+	}
+
+	err := fmt.Errorf("tx %v: nonce too low", txs[1].Hash())
+	assert.Equal(t, []common.Address{arg.Bob.Address()}, rejectedSenders(txs, err))
+
+	// the senders of all the txs if the error names none of them
+	err = errors.New("nonce too high")
+	assert.Equal(t, []common.Address{arg.Root.Address(), arg.Bob.Address()}, rejectedSenders(txs, err))
+}
+
+func TestIsNonceError(t *testing.T) {
+	assert.True(t, IsNonceError(errors.New("tx 0x01: nonce too low")))
+	assert.True(t, IsNonceError(errors.New("Nonce too high")))
+	assert.False(t, IsNonceError(errors.New("insufficient funds")))
+	assert.False(t, IsNonceError(nil))
+}
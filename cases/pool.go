@@ -0,0 +1,217 @@
+package cases
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+
+	"github.com/bnb-chain/bsc-mev-cases/abc"
+	"github.com/bnb-chain/bsc-mev-cases/utils"
+)
+
+// SeedFromMnemonic returns the BIP-39 seed of the mnemonic without passphrase.
+func SeedFromMnemonic(mnemonic string) ([]byte, error) {
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, errors.New("invalid mnemonic")
+	}
+
+	return bip39.NewSeed(mnemonic, ""), nil
+}
+
+// AccountSeed returns the seed of the mnemonic, or the hex seed if the mnemonic is empty.
+func AccountSeed(mnemonic, hexSeed string) ([]byte, error) {
+	if mnemonic != "" {
+		return SeedFromMnemonic(mnemonic)
+	}
+
+	seed, err := hexutil.Decode(hexSeed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid seed, %v", err)
+	}
+
+	// the length allowed by BIP-32
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("seed of %v bytes, expected 16 to 64", len(seed))
+	}
+
+	return seed, nil
+}
+
+// LoadAccountSeed returns the AccountSeed of the mnemonic, or of the hex seed if the mnemonic is
+// empty, both read from their specs of utils.LoadSecret.
+func LoadAccountSeed(mnemonicSpec, seedSpec string) ([]byte, error) {
+	if mnemonicSpec != "" {
+		mnemonic, err := utils.LoadSecret(mnemonicSpec)
+		if err != nil {
+			return nil, err
+		}
+		return AccountSeed(mnemonic, "")
+	}
+
+	hexSeed, err := utils.LoadSecret(seedSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	return AccountSeed("", hexSeed)
+}
+
+// DeriveAccounts derives n accounts from the seed at m/44'/60'/0'/0/i, the same as wallets
+// of the mnemonic of the seed.
+func DeriveAccounts(seed []byte, n int, abc *abc.Abc) ([]*Account, error) {
+	accs := make([]*Account, 0, n)
+	path := make(accounts.DerivationPath, len(accounts.DefaultBaseDerivationPath))
+	copy(path, accounts.DefaultBaseDerivationPath)
+
+	for i := 0; i < n; i++ {
+		path[len(path)-1] = uint32(i)
+
+		key, err := deriveKey(seed, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive account %v, %v", path, err)
+		}
+
+		accs = append(accs, newAccount(NewKeySigner(key), abc))
+	}
+
+	return accs, nil
+}
+
+// deriveKey derives the private key of the path from the seed by BIP-32.
+func deriveKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	n := crypto.S256().Params().N
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key, chainCode := new(big.Int).SetBytes(sum[:32]), sum[32:]
+	if key.Sign() == 0 || key.Cmp(n) >= 0 {
+		return nil, errors.New("invalid master key")
+	}
+
+	for _, index := range path {
+		var data []byte
+		if index >= 0x80000000 {
+			data = append([]byte{0}, math.PaddedBigBytes(key, 32)...)
+		} else {
+			parent, err := crypto.ToECDSA(math.PaddedBigBytes(key, 32))
+			if err != nil {
+				return nil, err
+			}
+			data = crypto.CompressPubkey(&parent.PublicKey)
+		}
+		data = binary.BigEndian.AppendUint32(data, index)
+
+		mac = hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum = mac.Sum(nil)
+
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) >= 0 {
+			return nil, fmt.Errorf("invalid child key at index %v", index)
+		}
+
+		key = tweak.Add(tweak, key).Mod(tweak, n)
+		if key.Sign() == 0 {
+			return nil, fmt.Errorf("invalid child key at index %v", index)
+		}
+		chainCode = sum[32:]
+	}
+
+	return crypto.ToECDSA(math.PaddedBigBytes(key, 32))
+}
+
+// AccountPool hands out accounts funded by FundAccounts, an account is held by one case at a
+// time, so the txs of concurrent cases never share nonces. It is safe for concurrent use.
+type AccountPool struct {
+	mu       sync.Mutex
+	all      []*Account
+	free     []*Account
+	released chan struct{}
+}
+
+// NewAccountPool creates the pool of the accounts.
+func NewAccountPool(accounts []*Account) *AccountPool {
+	return &AccountPool{
+		all:      accounts,
+		free:     append([]*Account{}, accounts...),
+		released: make(chan struct{}),
+	}
+}
+
+// Accounts returns all the accounts of the pool, held or not.
+func (p *AccountPool) Accounts() []*Account {
+	return append([]*Account{}, p.all...)
+}
+
+// Acquire takes n accounts, it waits until n accounts are released or ctx is done.
+func (p *AccountPool) Acquire(ctx context.Context, n int) ([]*Account, error) {
+	if n > len(p.all) {
+		return nil, fmt.Errorf("acquire %v accounts of a pool of %v", n, len(p.all))
+	}
+
+	for {
+		p.mu.Lock()
+		if len(p.free) >= n {
+			taken := append([]*Account{}, p.free[:n]...)
+			p.free = p.free[n:]
+			p.mu.Unlock()
+			return taken, nil
+		}
+		released := p.released
+		p.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("acquire %v accounts, %w", n, ctx.Err())
+		case <-released:
+		}
+	}
+}
+
+// Release gives back the accounts taken by Acquire.
+func (p *AccountPool) Release(accounts ...*Account) {
+	if len(accounts) == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.free = append(p.free, accounts...)
+	close(p.released)
+	p.released = make(chan struct{})
+}
+
+// acquireSenders takes the senders of the case from Pool, root sends the txs if Pool is nil.
+func (arg *BidCaseArg) acquireSenders() error {
+	if arg.Pool == nil {
+		return nil
+	}
+
+	n := arg.SendersPerCase
+	if n <= 0 {
+		n = 1
+	}
+
+	senders, err := arg.Pool.Acquire(arg.Ctx, n)
+	if err != nil {
+		return err
+	}
+
+	arg.senders = senders
+	return nil
+}
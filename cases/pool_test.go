@@ -0,0 +1,126 @@
+package cases
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveAccounts(t *testing.T) {
+	seed, err := AccountSeed("test test test test test test test test test test test junk", "")
+	assert.Nil(t, err)
+
+	accounts, err := DeriveAccounts(seed, 2, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"), accounts[0].Address)
+	assert.Equal(t, common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"), accounts[1].Address)
+
+	_, err = AccountSeed("test test test", "")
+	assert.NotNil(t, err)
+	_, err = AccountSeed("", "0x0102")
+	assert.NotNil(t, err)
+	_, err = AccountSeed("", "0x000102030405060708090a0b0c0d0e0f")
+	assert.Nil(t, err)
+
+	t.Setenv("TEST_MNEMONIC", "test test test test test test test test test test test junk")
+	loaded, err := LoadAccountSeed("env:TEST_MNEMONIC", "")
+	assert.Nil(t, err)
+	assert.Equal(t, seed, loaded)
+	_, err = LoadAccountSeed("test test test test test test test test test test test junk", "")
+	assert.NotNil(t, err)
+}
+
+func TestAccountPool(t *testing.T) {
+	accounts, err := DeriveAccounts(make([]byte, 32), 3, nil)
+	assert.Nil(t, err)
+	pool := NewAccountPool(accounts)
+
+	ctx := context.Background()
+	taken, err := pool.Acquire(ctx, 2)
+	assert.Nil(t, err)
+	assert.Len(t, taken, 2)
+
+	_, err = pool.Acquire(ctx, 4)
+	assert.ErrorContains(t, err, "pool of 3")
+
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = pool.Acquire(timeout, 2)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	done := make(chan []*Account)
+	go func() {
+		more, _ := pool.Acquire(ctx, 2)
+		done <- more
+	}()
+
+	pool.Release(taken...)
+	more := <-done
+	assert.Len(t, more, 2)
+	assert.NotEqual(t, more[0].Address, more[1].Address)
+}
+
+func TestPoolSenders(t *testing.T) {
+	if testing.Short() {
+		t.Skip("funding waits for receipts")
+	}
+
+	arg, server := newTestArg(t, 200*time.Millisecond)
+	root := newAccount(arg.Root, nil)
+
+	accounts, err := DeriveAccounts(common.FromHex("0x000102030405060708090a0b0c0d0e0f"), 3, nil)
+	assert.Nil(t, err)
+
+	target := new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18))
+	txs, err := FundAccounts(arg.Ctx, arg.FullNode, root, accounts, FundConfig{BNB: target})
+	assert.Nil(t, err)
+	assert.Len(t, txs, 3)
+	for _, acc := range accounts {
+		assert.Equal(t, target, acc.BalanceBNB(arg.Ctx, arg.FullNode))
+	}
+
+	// funded already
+	txs, err = FundAccounts(arg.Ctx, arg.FullNode, root, accounts, FundConfig{BNB: target})
+	assert.Nil(t, err)
+	assert.Empty(t, txs)
+
+	arg.Pool = NewAccountPool(accounts)
+	arg.SendersPerCase = 2
+
+	res := runCaseFn(arg, "Senders", func(arg *BidCaseArg) error {
+		txs := GenerateBNBTxs(arg, TransferAmountPerTx, 5)
+		signer := types.LatestSignerForChainID(arg.chainID())
+
+		for i, tx := range txs {
+			from, err := types.Sender(signer, tx)
+			assert.Nil(t, err)
+			assert.Equal(t, arg.senders[i%2].Address, from)
+		}
+		return nil
+	})
+	assert.Equal(t, StatusPassed, res.Status, res.Error)
+
+	c, err := Lookup("ValidBid_NilPayBidTx_200")
+	assert.Nil(t, err)
+	res = runCaseFn(arg, c.Name, c.Fn)
+	assert.Equal(t, StatusPassed, res.Status, "%s: %s", res.Name, res.Error)
+
+	// the 200 txs are sent by 2 of the accounts, the pool has all of them back
+	sent := uint64(0)
+	for _, acc := range accounts {
+		nonce, err := arg.FullNode.NonceAt(arg.Ctx, acc.Address, nil)
+		assert.Nil(t, err)
+		sent += nonce
+	}
+	assert.Equal(t, uint64(200), sent)
+
+	taken, err := arg.Pool.Acquire(arg.Ctx, 3)
+	assert.Nil(t, err)
+	assert.Len(t, taken, 3)
+	assert.Empty(t, server.Issues())
+}
@@ -2,37 +2,35 @@ package cases
 
 import (
 	"math/big"
-
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
-var queryCases = map[string]BidCaseFn{
-	"MevRunning":    MevRunning,
-	"BestBidGasFee": BestBidGasFee,
-	"MevParams":     MevParams,
-}
-
-var fullNode *ethclient.Client
-
 func init() {
-	var err error
-	fullNode, err = ethclient.Dial("http://localhost:8545")
-	if err != nil {
-		panic(err)
-	}
+	Register(&CaseInfo{
+		Name:        "MevRunning",
+		Description: "query mev_running",
+		Tags:        []Tag{TagQuery},
+		Expect:      ExpectQueryOK,
+		Fn:          MevRunning,
+	})
+	Register(&CaseInfo{
+		Name:        "BestBidGasFee",
+		Description: "query mev_bestBidGasFee of the latest block",
+		Tags:        []Tag{TagQuery},
+		Expect:      ExpectQueryOK,
+		Fn:          BestBidGasFee,
+	})
+	Register(&CaseInfo{
+		Name:        "MevParams",
+		Description: "query mev_params",
+		Tags:        []Tag{TagQuery},
+		Expect:      ExpectQueryOK,
+		Fn:          MevParams,
+	})
 }
 
-func RunQueryCases(arg *BidCaseArg) {
-	for n, c := range queryCases {
-		print("run case ", n)
-		err := c(arg)
-		if err != nil {
-			print(" failed: ", err.Error())
-		} else {
-			print(" succeed")
-		}
-		println()
-	}
+// RunQueryCases runs the cases tagged query.
+func RunQueryCases(arg *BidCaseArg) []*CaseResult {
+	return RunCases(arg, TagQuery)
 }
 
 func MevRunning(arg *BidCaseArg) error {
@@ -45,17 +43,17 @@ func MevRunning(arg *BidCaseArg) error {
 }
 
 func BestBidGasFee(arg *BidCaseArg) error {
-	number, err := fullNode.BlockNumber(arg.Ctx)
+	number, err := arg.FullNode.BlockNumber(arg.Ctx)
 	if err != nil {
 		return err
 	}
 
-	block, err := fullNode.BlockByNumber(arg.Ctx, big.NewInt(int64(number)))
+	block, err := arg.FullNode.BlockByNumber(arg.Ctx, big.NewInt(int64(number)))
 	if err != nil {
 		return err
 	}
 
-	_, err = arg.Client.BestBidGasFee(arg.Ctx, block.Hash())
+	_, err = arg.mevClient(number+1).BestBidGasFee(arg.Ctx, block.Hash())
 	if err != nil {
 		return err
 	}
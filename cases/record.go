@@ -0,0 +1,147 @@
+package cases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/bnb-chain/bsc-mev-cases/log"
+)
+
+// BidRecord is a bid sent to a validator with the head of the chain when it was sent and
+// the response, one JSON line of the file written by BidRecorder.
+type BidRecord struct {
+	Time time.Time `json:"time"`
+	// Case is the name of the case sending the bid, empty if not sent by a case
+	Case      string         `json:"case,omitempty"`
+	Validator common.Address `json:"validator"`
+	// HeadNumber and HeadHash are the head of FullNode when the bid is sent, zero if unknown
+	HeadNumber uint64         `json:"headNumber"`
+	HeadHash   common.Hash    `json:"headHash"`
+	Bid        *types.BidArgs `json:"bid"`
+
+	// BidHash is the hash returned by mev_sendBid if the bid is accepted
+	BidHash common.Hash `json:"bidHash"`
+	// ErrorCode is the json-rpc error code, 0 if accepted or not a json-rpc error
+	ErrorCode int    `json:"errorCode,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Latency is the time mev_sendBid takes to respond
+	Latency time.Duration `json:"latencyNs"`
+}
+
+// respond sets the response of mev_sendBid to the record.
+func (r *BidRecord) respond(hash common.Hash, err error, latency time.Duration) *BidRecord {
+	r.BidHash = hash
+	r.Latency = latency
+	r.ErrorCode, r.Error = errorCode(err)
+	return r
+}
+
+// errorCode returns the json-rpc error code and the message of err.
+func errorCode(err error) (int, string) {
+	if err == nil {
+		return 0, ""
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.ErrorCode(), err.Error()
+	}
+
+	return 0, err.Error()
+}
+
+// BidRecorder appends BidRecord as JSON lines, it is safe for concurrent use.
+type BidRecorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewBidRecorder creates the recorder writing to w.
+func NewBidRecorder(w io.Writer) *BidRecorder {
+	return &BidRecorder{w: w}
+}
+
+// OpenBidRecorder creates the recorder appending to the file of the path, the file is created
+// if it does not exist.
+func OpenBidRecorder(path string) (*BidRecorder, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBidRecorder(f), nil
+}
+
+// Record appends the record, each record is written by a single write.
+func (r *BidRecorder) Record(record *BidRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err = r.w.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the underlying writer if it is a closer.
+func (r *BidRecorder) Close() error {
+	if c, ok := r.w.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// ReadBidRecords reads the records of a file written by BidRecorder.
+func ReadBidRecords(path string) ([]*BidRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []*BidRecord
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		record := new(BidRecord)
+		if err = dec.Decode(record); err != nil {
+			return nil, fmt.Errorf("failed to decode record %v of %v, %v", len(records), path, err)
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// newBidRecord creates the record of a bid about to be sent, with the head of FullNode.
+func (arg *BidCaseArg) newBidRecord(bidArgs *types.BidArgs, number uint64) *BidRecord {
+	record := &BidRecord{
+		Time:      time.Now(),
+		Case:      arg.caseName(),
+		Validator: arg.validatorAt(number),
+		Bid:       bidArgs,
+	}
+
+	head, err := arg.FullNode.HeaderByNumber(arg.Ctx, nil)
+	if err != nil {
+		log.Errorw("failed to get head of recorded bid", "err", err)
+		return record
+	}
+
+	record.HeadNumber = head.Number.Uint64()
+	record.HeadHash = head.Hash()
+	return record
+}
@@ -0,0 +1,83 @@
+package cases
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	arg, server := newTestArg(t, 0)
+	path := filepath.Join(t.TempDir(), "bids.jsonl")
+
+	// a stale bid 10 blocks behind is not at block 0
+	for i := 0; i < 10; i++ {
+		_, err := server.Chain().Seal()
+		assert.Nil(t, err)
+	}
+
+	recorder, err := OpenBidRecorder(path)
+	assert.Nil(t, err)
+	arg.Recorder = recorder
+
+	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 3)
+	acc, err := simulateBid(arg, txs)
+	assert.Nil(t, err)
+	valid, err := generateValidBid(arg, txs, int64(acc.GasUsed()), acc.GasFee(), true, BuilderFee)
+	assert.Nil(t, err)
+
+	stale, err := MutateBid(valid, WithBlockOffset(-10), Resign(arg.Builder))
+	assert.Nil(t, err)
+	corrupted, err := MutateBid(valid, CorruptSignature())
+	assert.Nil(t, err)
+
+	bids := []*types.BidArgs{valid, stale, corrupted}
+	for _, bid := range bids {
+		_, _ = arg.sendBid(bid)
+	}
+	assert.Nil(t, recorder.Close())
+
+	records, err := ReadBidRecords(path)
+	assert.Nil(t, err)
+	assert.Len(t, records, 3)
+
+	head := server.Chain().Head()
+	for i, record := range records {
+		assert.Equal(t, head.NumberU64(), record.HeadNumber)
+		assert.Equal(t, head.Hash(), record.HeadHash)
+		assert.Equal(t, server.Validator(), record.Validator)
+		assert.Equal(t, bids[i].RawBid.Hash(), record.Bid.RawBid.Hash())
+		assert.Positive(t, record.Latency)
+	}
+	assert.Equal(t, valid.RawBid.Hash(), records[0].BidHash)
+	assert.Zero(t, records[0].ErrorCode)
+	assert.Equal(t, types.InvalidBidParamError, records[1].ErrorCode)
+	assert.Equal(t, types.InvalidBidParamError, records[2].ErrorCode)
+
+	// replayed on a later head, not recorded again
+	_, err = server.Chain().Seal()
+	assert.Nil(t, err)
+	_, err = server.Chain().Seal()
+	assert.Nil(t, err)
+	arg.Recorder = nil
+
+	results, err := Replay(arg, records, ReplayConfig{})
+	assert.Nil(t, err)
+	assert.Len(t, results, 3)
+	for _, res := range results {
+		assert.True(t, res.Matches(), "recorded %v %v, replayed %v %v",
+			res.Record.ErrorCode, res.Record.Error, res.ErrorCode, res.Error)
+	}
+
+	head = server.Chain().Head()
+	replayed := results[0].Bid
+	assert.Equal(t, head.NumberU64()+1, replayed.RawBid.BlockNumber)
+	assert.Equal(t, head.Hash(), replayed.RawBid.ParentHash)
+	assert.Nil(t, VerifyBidSignature(replayed, arg.Builder.Address))
+	assert.Nil(t, VerifyBidTxs(replayed, arg.chainID()))
+
+	assert.Equal(t, head.NumberU64()+1-10, results[1].Bid.RawBid.BlockNumber)
+	assert.Equal(t, bids[2].Signature, results[2].Bid.Signature)
+}
@@ -0,0 +1,148 @@
+package cases
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Tag classifies a case, cases are selected into suites by tag.
+type Tag string
+
+const (
+	TagValid   Tag = "valid"
+	TagInvalid Tag = "invalid"
+	TagABC     Tag = "abc"
+	TagStable  Tag = "stable"
+	TagQuery   Tag = "query"
+	TagPayBid  Tag = "pay-bid"
+	TagTxTypes Tag = "tx-types"
+	// TagCompetition is of the cases where several builders bid for the same block
+	TagCompetition Tag = "competition"
+	// TagScenario is of the cases loaded from scenario files
+	TagScenario Tag = "scenario"
+)
+
+// Outcome is the result a case expects from the validator.
+type Outcome string
+
+const (
+	// ExpectTxsSucceed expects the bid accepted and all of its txs on chain with status 1.
+	ExpectTxsSucceed Outcome = "txs-succeed"
+	// ExpectNoError expects the bid accepted by mev_sendBid, whatever happens to it later.
+	ExpectNoError Outcome = "no-error"
+	// ExpectNotSealed expects the bid accepted by mev_sendBid but not sealed in its block.
+	ExpectNotSealed Outcome = "not-sealed"
+	// ExpectInvalidBidParam expects mev_sendBid to return InvalidBidParamError.
+	ExpectInvalidBidParam Outcome = "invalid-bid-param"
+	// ExpectInvalidPayBidTx expects mev_sendBid to return InvalidPayBidTxError.
+	ExpectInvalidPayBidTx Outcome = "invalid-pay-bid-tx"
+	// ExpectErrorCode expects mev_sendBid to return the error code of the scenario.
+	ExpectErrorCode Outcome = "error-code"
+	// ExpectBestBidSealed expects the bid of the highest reward among the competing builders
+	// reported by BestBidGasFee and sealed.
+	ExpectBestBidSealed Outcome = "best-bid-sealed"
+	// ExpectQueryOK expects the mev query api to return without error.
+	ExpectQueryOK Outcome = "query-ok"
+)
+
+// CaseInfo describes a registered case.
+type CaseInfo struct {
+	Name        string
+	Description string
+	Tags        []Tag
+	Expect      Outcome
+	// EstimatedCost is the estimated BNB spent by one run of the case
+	EstimatedCost float64
+	Fn            BidCaseFn
+}
+
+// HasTag reports whether the case is tagged with any of the given tags.
+func (c *CaseInfo) HasTag(tags ...Tag) bool {
+	for _, t := range tags {
+		for _, ct := range c.Tags {
+			if ct == t {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+var registry = make(map[string]*CaseInfo)
+
+// Register adds a case to the registry, it panics if the name is empty or already registered.
+func Register(info *CaseInfo) {
+	if info.Name == "" || info.Fn == nil {
+		panic("cases: register case without name or fn")
+	}
+
+	if _, ok := registry[info.Name]; ok {
+		panic(fmt.Sprintf("cases: case %s registered twice", info.Name))
+	}
+
+	registry[info.Name] = info
+}
+
+// Lookup returns the case registered with the name.
+func Lookup(name string) (*CaseInfo, error) {
+	info, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("case %s not found", name)
+	}
+
+	return info, nil
+}
+
+// Cases returns the cases tagged with any of the given tags sorted by name,
+// all registered cases are returned if no tag given.
+func Cases(tags ...Tag) []*CaseInfo {
+	infos := make([]*CaseInfo, 0, len(registry))
+	for _, info := range registry {
+		if len(tags) == 0 || info.HasTag(tags...) {
+			infos = append(infos, info)
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	return infos
+}
+
+// ParseTags parses a comma separated tag list, e.g. "valid,pay-bid".
+func ParseTags(s string) []Tag {
+	tags := make([]Tag, 0)
+	for _, t := range strings.Split(s, ",") {
+		t = strings.TrimSpace(t)
+		if t != "" {
+			tags = append(tags, Tag(t))
+		}
+	}
+
+	return tags
+}
+
+// PrintCases writes the cases as a table.
+func PrintCases(w io.Writer, infos []*CaseInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTAGS\tEXPECT\tCOST(BNB)\tDESCRIPTION")
+	for _, c := range infos {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%g\t%s\n", c.Name, tagsString(c.Tags), c.Expect, c.EstimatedCost, c.Description)
+	}
+
+	return tw.Flush()
+}
+
+func tagsString(tags []Tag) string {
+	ss := make([]string, 0, len(tags))
+	for _, t := range tags {
+		ss = append(ss, string(t))
+	}
+
+	return strings.Join(ss, ",")
+}
@@ -0,0 +1,33 @@
+package cases
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCasesByTag(t *testing.T) {
+	for _, c := range Cases(TagPayBid) {
+		assert.True(t, c.HasTag(TagPayBid), c.Name)
+	}
+
+	query := Cases(TagQuery)
+	assert.Len(t, query, 3)
+	assert.Equal(t, "BestBidGasFee", query[0].Name)
+
+	assert.Len(t, Cases(), len(registry))
+}
+
+func TestLookup(t *testing.T) {
+	c, err := Lookup("MevRunning")
+	assert.Nil(t, err)
+	assert.Equal(t, ExpectQueryOK, c.Expect)
+
+	_, err = Lookup("NotExist")
+	assert.NotNil(t, err)
+}
+
+func TestParseTags(t *testing.T) {
+	assert.Equal(t, []Tag{TagValid, TagPayBid}, ParseTags(" valid,,pay-bid "))
+	assert.Empty(t, ParseTags(""))
+}
@@ -0,0 +1,135 @@
+package cases
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/bnb-chain/bsc-mev-cases/log"
+)
+
+// ReplayConfig configures a replay of recorded bids.
+type ReplayConfig struct {
+	// Speed scales the intervals between the recorded bids, e.g. 2 replays twice as fast,
+	// the bids are sent back to back if 0
+	Speed float64
+}
+
+// ReplayResult is the response to a recorded bid sent again.
+type ReplayResult struct {
+	Record *BidRecord
+	// Bid is the bid sent, re-targeted to the head and re-signed
+	Bid       *types.BidArgs
+	BidHash   common.Hash
+	ErrorCode int
+	Error     string
+}
+
+// Matches reports whether the bid is answered the same as recorded, i.e. both accepted or both
+// rejected with the same error code.
+func (r *ReplayResult) Matches() bool {
+	return (r.Error == "") == (r.Record.Error == "") && r.ErrorCode == r.Record.ErrorCode
+}
+
+// Replay sends the recorded bids again in order, each re-targeted to the head of FullNode and
+// re-signed by arg.Builder, see retarget. It fails only if the chain can't be read.
+func Replay(arg *BidCaseArg, records []*BidRecord, config ReplayConfig) ([]*ReplayResult, error) {
+	chainID := arg.chainID()
+
+	results := make([]*ReplayResult, 0, len(records))
+	for i, record := range records {
+		if i > 0 && config.Speed > 0 {
+			interval := record.Time.Sub(records[i-1].Time)
+			if err := sleepCtx(arg.Ctx, time.Duration(float64(interval)/config.Speed)); err != nil {
+				return results, fmt.Errorf("replay stopped after %v bids, %w", i, err)
+			}
+		}
+
+		head, err := arg.FullNode.HeaderByNumber(arg.Ctx, nil)
+		if err != nil {
+			return results, fmt.Errorf("failed to get head, %v", err)
+		}
+
+		bidArgs, err := retarget(arg, record, head, chainID)
+		if err != nil {
+			return results, fmt.Errorf("failed to retarget bid %v, %v", i, err)
+		}
+
+		hash, err := arg.sendBid(bidArgs)
+		code, msg := errorCode(err)
+
+		res := &ReplayResult{Record: record, Bid: bidArgs, BidHash: hash, ErrorCode: code, Error: msg}
+		if !res.Matches() {
+			log.Warnw("replayed bid answered differently", "index", i, "case", record.Case,
+				"recordedCode", record.ErrorCode, "recordedErr", record.Error, "code", code, "err", msg)
+		}
+
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+// retarget returns a copy of the recorded bid on top of head, so it is judged by the validator the
+// same way as when recorded:
+//   - the block number keeps its offset to the recorded head, e.g. a stale bid stays stale
+//   - the parent hash is the head if it was the recorded head, otherwise it is kept
+//   - a decodable PayBidTx is created again by the builder for the validator in turn
+//   - the bid is re-signed by the builder if it was signed, a corrupted signature is kept
+//
+// The txs of the bid are sent as recorded.
+func retarget(arg *BidCaseArg, record *BidRecord, head *types.Header, chainID *big.Int) (*types.BidArgs, error) {
+	bid := record.Bid
+	if bid == nil {
+		return &types.BidArgs{}, nil
+	}
+	if bid.RawBid == nil {
+		return MutateBid(bid)
+	}
+
+	raw := bid.RawBid
+	headKnown := record.HeadHash != (common.Hash{})
+
+	offset := int64(0)
+	if headKnown {
+		offset = int64(raw.BlockNumber) - int64(record.HeadNumber+1)
+	}
+	number := uint64(int64(head.Number.Uint64()+1) + offset)
+
+	mutators := []BidMutator{WithBlockNumber(number)}
+	if !headKnown || raw.ParentHash == record.HeadHash {
+		mutators = append(mutators, WithParentHash(head.Hash()))
+	}
+
+	if len(bid.PayBidTx) != 0 {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(bid.PayBidTx); err == nil {
+			payBidTx, err := arg.payBidTx(number, chainID, tx.Value())
+			if err != nil {
+				return nil, err
+			}
+			mutators = append(mutators, WithPayBidTx(payBidTx))
+		}
+	}
+
+	retargeted, err := MutateBid(bid, mutators...)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := crypto.SigToPub(raw.Hash().Bytes(), bid.Signature); err != nil {
+		return retargeted, nil
+	}
+
+	signed, err := arg.Builder.SignBid(retargeted.RawBid)
+	if err != nil {
+		return nil, err
+	}
+	retargeted.Signature = signed.Signature
+
+	return retargeted, nil
+}
@@ -0,0 +1,98 @@
+package cases
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WriteJSONLines writes one JSON object per result.
+func WriteJSONLines(w io.Writer, results []*CaseResult) error {
+	enc := json.NewEncoder(w)
+	for _, r := range results {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+}
+
+// WriteJUnit writes the results as a JUnit XML report of one test suite.
+func WriteJUnit(w io.Writer, suite string, results []*CaseResult) error {
+	ts := junitTestSuite{
+		Name:      suite,
+		Tests:     len(results),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Cases:     make([]junitTestCase, 0, len(results)),
+	}
+
+	var total time.Duration
+	for _, r := range results {
+		total += r.Duration
+
+		tc := junitTestCase{
+			Name:      r.Name,
+			ClassName: suite,
+			Time:      junitSeconds(r.Duration),
+			SystemOut: fmt.Sprintf("retries=%d blockNumber=%d bidHash=%s", r.Retries, r.BlockNumber, r.BidHash.Hex()),
+		}
+
+		if r.Failed() {
+			ts.Failures++
+			tc.Failure = &junitFailure{
+				Message: r.Error,
+				Type:    string(r.Status),
+			}
+		}
+
+		ts.Cases = append(ts.Cases, tc)
+	}
+	ts.Time = junitSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{ts}}); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,50 @@
+package cases
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func testResults() []*CaseResult {
+	passed := &CaseResult{Name: "ValidBid_NilPayBidTx_200", BlockNumber: 10, BidHash: common.HexToHash("0x01")}
+	passed.finish(time.Now(), nil)
+	failed := &CaseResult{Name: "InvalidBid_NilNumber_20", Retries: 2}
+	failed.finish(time.Now(), errors.New("expect error but return nil"))
+
+	return []*CaseResult{passed, failed}
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, WriteJSONLines(&buf, testResults()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var res map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &res))
+	assert.Equal(t, "passed", res["status"])
+	assert.Equal(t, common.HexToHash("0x01").Hex(), res["bidHash"])
+
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &res))
+	assert.Equal(t, "failed", res["status"])
+	assert.Equal(t, "expect error but return nil", res["error"])
+	assert.EqualValues(t, 2, res["retries"])
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, WriteJUnit(&buf, "bidbot-valid", testResults()))
+
+	out := buf.String()
+	assert.Contains(t, out, `<testsuite name="bidbot-valid" tests="2" failures="1"`)
+	assert.Contains(t, out, `<failure message="expect error but return nil" type="failed">`)
+	assert.True(t, AnyFailed(testResults()))
+}
@@ -0,0 +1,170 @@
+package cases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/bnb-chain/bsc-mev-cases/log"
+)
+
+// Status is the final status of a case run.
+type Status string
+
+const (
+	StatusPassed Status = "passed"
+	StatusFailed Status = "failed"
+	// StatusTimeout is the status of a case failed after its deadline exceeded.
+	StatusTimeout Status = "timeout"
+)
+
+// CaseResult is the result of a case run.
+type CaseResult struct {
+	Name     string
+	Status   Status
+	Error    string
+	Duration time.Duration
+	// Retries is the number of bids sent by the case besides the first one
+	Retries int
+	// BlockNumber and BidHash are of the last bid sent by the case
+	BlockNumber uint64
+	BidHash     common.Hash
+
+	sent int
+}
+
+// Failed reports whether the case did not pass.
+func (r *CaseResult) Failed() bool {
+	return r.Status != StatusPassed
+}
+
+func (r *CaseResult) MarshalJSON() ([]byte, error) {
+	type result struct {
+		Name        string `json:"name"`
+		Status      Status `json:"status"`
+		Error       string `json:"error,omitempty"`
+		DurationMs  int64  `json:"durationMs"`
+		Retries     int    `json:"retries"`
+		BlockNumber uint64 `json:"blockNumber,omitempty"`
+		BidHash     string `json:"bidHash,omitempty"`
+	}
+
+	res := result{
+		Name:        r.Name,
+		Status:      r.Status,
+		Error:       r.Error,
+		DurationMs:  r.Duration.Milliseconds(),
+		Retries:     r.Retries,
+		BlockNumber: r.BlockNumber,
+	}
+
+	if r.BidHash != (common.Hash{}) {
+		res.BidHash = r.BidHash.Hex()
+	}
+
+	return json.Marshal(res)
+}
+
+// recordBid records a bid sent by the case, it is a no-op if arg is not run by a runner.
+func (arg *BidCaseArg) recordBid(bidArgs *types.BidArgs) {
+	r := arg.result
+	if r == nil || bidArgs.RawBid == nil {
+		return
+	}
+
+	if r.sent > 0 {
+		r.Retries++
+	}
+	r.sent++
+
+	r.BlockNumber = bidArgs.RawBid.BlockNumber
+	r.BidHash = bidArgs.RawBid.Hash()
+}
+
+// caseName returns the name of the case run by a runner, empty if arg is not run by a runner.
+func (arg *BidCaseArg) caseName() string {
+	if arg.result == nil {
+		return ""
+	}
+
+	return arg.result.Name
+}
+
+// runCaseFn runs fn with a copy of arg recording to a new result, under the deadline of
+// CaseTimeout.
+func runCaseFn(arg *BidCaseArg, name string, fn BidCaseFn) *CaseResult {
+	res := &CaseResult{Name: name}
+	caseArg := *arg
+	caseArg.result = res
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if arg.CaseTimeout > 0 {
+		ctx, cancel = context.WithTimeout(arg.Ctx, arg.CaseTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(arg.Ctx)
+	}
+	defer cancel()
+	caseArg.Ctx = ctx
+
+	start := time.Now()
+	err := caseArg.acquireSenders()
+	if err == nil {
+		err = callCaseFn(&caseArg, fn)
+	}
+	res.finish(start, err)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		res.Status = StatusTimeout
+	}
+	arg.Metrics.observeCase(res)
+
+	if arg.Nonces != nil {
+		if er := arg.Nonces.Settle(arg.Ctx); er != nil {
+			log.Errorw("failed to settle nonces", "err", er)
+		}
+	}
+
+	if arg.Pool != nil {
+		arg.Pool.Release(caseArg.senders...)
+	}
+
+	return res
+}
+
+// callCaseFn calls fn, a panic of fn fails the case instead of the whole run.
+func callCaseFn(arg *BidCaseArg, fn BidCaseFn) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return fn(arg)
+}
+
+func (r *CaseResult) finish(start time.Time, err error) {
+	r.Duration = time.Since(start)
+	if err != nil {
+		r.Status = StatusFailed
+		r.Error = err.Error()
+		return
+	}
+
+	r.Status = StatusPassed
+}
+
+// AnyFailed reports whether any of the results did not pass.
+func AnyFailed(results []*CaseResult) bool {
+	for _, r := range results {
+		if r.Failed() {
+			return true
+		}
+	}
+
+	return false
+}
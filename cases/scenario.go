@@ -0,0 +1,462 @@
+package cases
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Tokens of the txs of a scenario.
+const (
+	TokenBNB = "BNB"
+	TokenABC = "ABC"
+)
+
+// Types of the txs of a scenario.
+const (
+	TxTypeLegacy     = "legacy"
+	TxTypeAccessList = "access-list"
+	TxTypeDynamicFee = "dynamic-fee"
+	// TxTypeMixed is legacy, access list and dynamic fee txs in turn
+	TxTypeMixed = "mixed"
+	// TxTypeUnsigned is legacy txs without signature
+	TxTypeUnsigned = "unsigned"
+	// TxTypeFailed is legacy txs transferring more than the balance
+	TxTypeFailed = "failed"
+)
+
+// Accountings of the gasUsed and gasFee claimed by a bid before the mutations.
+const (
+	// AccountingSimulated is the gasUsed and gasFee of the txs simulated on the full node
+	AccountingSimulated = "simulated"
+	// AccountingFeeCap is the simulated gasUsed paying the fee cap of dynamic fee txs
+	AccountingFeeCap = "fee-cap"
+	// AccountingEstimated is the gas used of a transfer times the count of txs, at the default gas price
+	AccountingEstimated = "estimated"
+)
+
+// Scenario describes a case declaratively: the txs generated, the bid mutated and the outcome
+// expected. A scenario file is a list of scenarios, e.g. a scenario in YAML
+//
+//	name: Scenario_OldBlockNumber_20
+//	txs: {count: 20}
+//	bid: {blockNumberOffset: -10}
+//	expect: invalid-bid-param
+type Scenario struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	// Tags are added to TagScenario
+	Tags          []Tag   `json:"tags" yaml:"tags"`
+	EstimatedCost float64 `json:"estimatedCost" yaml:"estimatedCost"`
+
+	Txs ScenarioTxs `json:"txs" yaml:"txs"`
+	Bid ScenarioBid `json:"bid" yaml:"bid"`
+
+	Expect Outcome `json:"expect" yaml:"expect"`
+	// ErrorCode is the code expected by ExpectErrorCode
+	ErrorCode int `json:"errorCode" yaml:"errorCode"`
+	// Log are the messages of which any is expected in the validator log after the bid is sent
+	Log []string `json:"log" yaml:"log"`
+}
+
+// ScenarioTxs describes the txs of the bid, transferred from root to bob.
+type ScenarioTxs struct {
+	// Token is BNB if empty
+	Token string `json:"token" yaml:"token"`
+	// Type is legacy if empty
+	Type  string `json:"type" yaml:"type"`
+	Count int    `json:"count" yaml:"count"`
+	// Amount is the wei transferred by each tx, TransferAmountPerTx if nil
+	Amount *big.Int `json:"amount" yaml:"amount"`
+}
+
+// ScenarioBid describes how the bid is built from the txs and then mutated. The mutations are
+// applied after the bid is signed, the bid is not signed again unless Resign.
+type ScenarioBid struct {
+	// Accounting is simulated if empty, or estimated for unsigned and failed txs
+	Accounting string `json:"accounting" yaml:"accounting"`
+
+	// GasUsed overrides the gasUsed of the accounting, before GasUsedMultiplier
+	GasUsed           *uint64     `json:"gasUsed" yaml:"gasUsed"`
+	GasUsedMultiplier *Multiplier `json:"gasUsedMultiplier" yaml:"gasUsedMultiplier"`
+	// GasFee overrides the gasFee of the accounting, before GasFeeMultiplier
+	GasFee           *big.Int    `json:"gasFee" yaml:"gasFee"`
+	GasFeeMultiplier *Multiplier `json:"gasFeeMultiplier" yaml:"gasFeeMultiplier"`
+	NilGasFee        bool        `json:"nilGasFee" yaml:"nilGasFee"`
+
+	// BuilderFee pays the builder by PayBidTx if not nil
+	BuilderFee *big.Int `json:"builderFee" yaml:"builderFee"`
+
+	// BlockNumberOffset is added to the block number, after BlockNumber
+	BlockNumber       *uint64      `json:"blockNumber" yaml:"blockNumber"`
+	BlockNumberOffset int64        `json:"blockNumberOffset" yaml:"blockNumberOffset"`
+	ParentHash        *common.Hash `json:"parentHash" yaml:"parentHash"`
+	CorruptSignature  bool         `json:"corruptSignature" yaml:"corruptSignature"`
+
+	// DropPayBidTx removes PayBidTx, before PayBidTx
+	DropPayBidTx    bool           `json:"dropPayBidTx" yaml:"dropPayBidTx"`
+	PayBidTx        *hexutil.Bytes `json:"payBidTx" yaml:"payBidTx"`
+	PayBidTxGasUsed *uint64        `json:"payBidTxGasUsed" yaml:"payBidTxGasUsed"`
+
+	// Resign signs the raw bid again after the mutations, before CorruptSignature
+	Resign bool `json:"resign" yaml:"resign"`
+}
+
+// Multiplier is an exact decimal factor, e.g. 0.01, written as a number or a string.
+type Multiplier big.Rat
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *Multiplier) UnmarshalText(text []byte) error {
+	if _, ok := (*big.Rat)(m).SetString(string(text)); !ok {
+		return fmt.Errorf("invalid multiplier %q", text)
+	}
+
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *Multiplier) UnmarshalJSON(data []byte) error {
+	return m.UnmarshalText(bytes.Trim(data, `"`))
+}
+
+func (m *Multiplier) mul(x *big.Int) *big.Int {
+	if m == nil {
+		return x
+	}
+
+	r := (*big.Rat)(m)
+	z := new(big.Int).Mul(x, r.Num())
+	return z.Quo(z, r.Denom())
+}
+
+// LoadScenarios reads the scenarios of a YAML or JSON file, by the extension of the path.
+func LoadScenarios(path string) ([]*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var scenarios []*Scenario
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&scenarios)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&scenarios)
+	default:
+		return nil, fmt.Errorf("unknown scenario file extension %q", ext)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %v, %v", path, err)
+	}
+
+	for _, s := range scenarios {
+		if err = s.Validate(); err != nil {
+			return nil, fmt.Errorf("%v, %v", path, err)
+		}
+	}
+
+	return scenarios, nil
+}
+
+// RegisterScenarios registers the scenarios as cases, none is registered if any name is taken.
+func RegisterScenarios(scenarios []*Scenario) error {
+	names := make(map[string]bool, len(scenarios))
+	for _, s := range scenarios {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+
+		if _, err := Lookup(s.Name); err == nil || names[s.Name] {
+			return fmt.Errorf("scenario %s registered twice", s.Name)
+		}
+		names[s.Name] = true
+	}
+
+	for _, s := range scenarios {
+		Register(s.CaseInfo())
+	}
+
+	return nil
+}
+
+// RunScenarioCases runs the cases tagged scenario.
+func RunScenarioCases(arg *BidCaseArg) []*CaseResult {
+	return RunCases(arg, TagScenario)
+}
+
+// Validate checks the scenario can be run.
+func (s *Scenario) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("scenario without name")
+	}
+
+	switch s.Txs.token() {
+	case TokenBNB:
+		switch s.Txs.txType() {
+		case TxTypeLegacy, TxTypeAccessList, TxTypeDynamicFee, TxTypeMixed, TxTypeUnsigned, TxTypeFailed:
+		default:
+			return fmt.Errorf("scenario %s, unknown tx type %q", s.Name, s.Txs.Type)
+		}
+	case TokenABC:
+		if s.Txs.txType() != TxTypeLegacy {
+			return fmt.Errorf("scenario %s, %v txs of ABC not supported", s.Name, s.Txs.Type)
+		}
+	default:
+		return fmt.Errorf("scenario %s, unknown token %q", s.Name, s.Txs.Token)
+	}
+
+	if s.Txs.Count < 0 {
+		return fmt.Errorf("scenario %s, negative tx count %v", s.Name, s.Txs.Count)
+	}
+
+	switch s.accounting() {
+	case AccountingEstimated:
+	case AccountingSimulated, AccountingFeeCap:
+		if t := s.Txs.txType(); t == TxTypeUnsigned || t == TxTypeFailed {
+			return fmt.Errorf("scenario %s, %v txs can not be simulated", s.Name, t)
+		}
+	default:
+		return fmt.Errorf("scenario %s, unknown accounting %q", s.Name, s.Bid.Accounting)
+	}
+
+	switch s.Expect {
+	case ExpectTxsSucceed, ExpectNoError, ExpectNotSealed, ExpectInvalidBidParam, ExpectInvalidPayBidTx:
+	case ExpectErrorCode:
+		if s.ErrorCode == 0 {
+			return fmt.Errorf("scenario %s, expect error code without errorCode", s.Name)
+		}
+	default:
+		return fmt.Errorf("scenario %s, unknown outcome %q", s.Name, s.Expect)
+	}
+
+	return nil
+}
+
+// CaseInfo returns the case running the scenario, tagged scenario.
+func (s *Scenario) CaseInfo() *CaseInfo {
+	description := s.Description
+	if description == "" {
+		description = fmt.Sprintf("scenario of %v %v %v txs", s.Txs.Count, s.Txs.txType(), s.Txs.token())
+	}
+
+	return &CaseInfo{
+		Name:          s.Name,
+		Description:   description,
+		Tags:          append([]Tag{TagScenario}, s.Tags...),
+		Expect:        s.Expect,
+		EstimatedCost: s.EstimatedCost,
+		Fn:            s.run,
+	}
+}
+
+func (t *ScenarioTxs) token() string {
+	if t.Token == "" {
+		return TokenBNB
+	}
+
+	return strings.ToUpper(t.Token)
+}
+
+func (t *ScenarioTxs) txType() string {
+	if t.Type == "" {
+		return TxTypeLegacy
+	}
+
+	return t.Type
+}
+
+func (s *Scenario) accounting() string {
+	if s.Bid.Accounting != "" {
+		return s.Bid.Accounting
+	}
+
+	if t := s.Txs.txType(); t == TxTypeUnsigned || t == TxTypeFailed {
+		return AccountingEstimated
+	}
+
+	return AccountingSimulated
+}
+
+func (s *Scenario) run(arg *BidCaseArg) error {
+	mark := arg.logMark()
+	txs := s.generateTxs(arg)
+	gasUsed, gasFee, err := s.gas(arg, txs)
+	if err != nil {
+		return err
+	}
+
+	bidArgs, err := s.bid(arg, txs, gasUsed, gasFee)
+	if err != nil {
+		return err
+	}
+
+	retry, err := s.assert(arg, bidArgs, txs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = s.bid(arg, txs, gasUsed, gasFee); err != nil {
+			return err
+		}
+		retry, err = s.assert(arg, bidArgs, txs)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(s.Log) == 0 {
+		return nil
+	}
+
+	return arg.expectLog(mark, s.Log)
+}
+
+func (s *Scenario) generateTxs(arg *BidCaseArg) types.Transactions {
+	if s.Txs.Count == 0 {
+		return nil
+	}
+
+	amount := s.Txs.Amount
+	if amount == nil {
+		amount = TransferAmountPerTx
+	}
+
+	if s.Txs.token() == TokenABC {
+		return generateABCTxs(arg, amount, s.Txs.Count)
+	}
+
+	bob := arg.Bob.Address()
+	mixed := MixedTxOptions(bob)
+
+	switch s.Txs.txType() {
+	case TxTypeAccessList:
+		return GenerateBNBTxsWithOptions(arg, amount, s.Txs.Count, mixed[1])
+	case TxTypeDynamicFee:
+		return GenerateBNBTxsWithOptions(arg, amount, s.Txs.Count, mixed[2])
+	case TxTypeMixed:
+		return GenerateBNBTxsWithOptions(arg, amount, s.Txs.Count, mixed...)
+	case TxTypeUnsigned:
+		return generateBNBTxsNoSign(arg, amount, s.Txs.Count)
+	case TxTypeFailed:
+		return generateBNBFailedTxs(arg, s.Txs.Count)
+	default:
+		return GenerateBNBTxs(arg, amount, s.Txs.Count)
+	}
+}
+
+// gas returns the gasUsed and gasFee claimed by the bid.
+func (s *Scenario) gas(arg *BidCaseArg, txs types.Transactions) (int64, *big.Int, error) {
+	var gasUsed uint64
+	var gasFee *big.Int
+
+	switch s.accounting() {
+	case AccountingEstimated:
+		perTx, gasPrice := BNBGasUsed, DefaultBNBGasPrice
+		if s.Txs.token() == TokenABC {
+			perTx, gasPrice = ABCGasUsed, DefaultABCGasPrice
+		}
+
+		gasUsed = uint64(perTx) * uint64(s.Txs.Count)
+		gasFee = new(big.Int).Mul(new(big.Int).SetUint64(gasUsed), gasPrice)
+	case AccountingFeeCap:
+		acc, err := simulateBid(arg, txs)
+		if err != nil {
+			return 0, nil, err
+		}
+		gasUsed, gasFee = acc.GasUsed(), acc.gasFee(nil)
+	default:
+		acc, err := simulateBid(arg, txs)
+		if err != nil {
+			return 0, nil, err
+		}
+		gasUsed, gasFee = acc.GasUsed(), acc.GasFee()
+	}
+
+	if s.Bid.GasUsed != nil {
+		gasUsed = *s.Bid.GasUsed
+	}
+	gasUsed = s.Bid.GasUsedMultiplier.mul(new(big.Int).SetUint64(gasUsed)).Uint64()
+
+	if s.Bid.GasFee != nil {
+		gasFee = s.Bid.GasFee
+	}
+	gasFee = s.Bid.GasFeeMultiplier.mul(gasFee)
+
+	if s.Bid.NilGasFee {
+		gasFee = nil
+	}
+
+	return int64(gasUsed), gasFee, nil
+}
+
+// bid generates the bid on the latest block, and mutates it.
+func (s *Scenario) bid(arg *BidCaseArg, txs types.Transactions, gasUsed int64, gasFee *big.Int) (*types.BidArgs, error) {
+	builderFee := s.Bid.BuilderFee
+	return generateMutatedBid(arg, txs, gasUsed, gasFee, builderFee != nil, builderFee, s.Bid.mutators(arg.Builder)...)
+}
+
+// mutators returns the mutators of the bid, in the order documented by ScenarioBid.
+func (b *ScenarioBid) mutators(builder *Account) []BidMutator {
+	mutators := make([]BidMutator, 0)
+	if b.BlockNumber != nil {
+		mutators = append(mutators, WithBlockNumber(*b.BlockNumber))
+	}
+
+	if b.BlockNumberOffset != 0 {
+		mutators = append(mutators, WithBlockOffset(b.BlockNumberOffset))
+	}
+
+	if b.ParentHash != nil {
+		mutators = append(mutators, WithParentHash(*b.ParentHash))
+	}
+
+	if b.Resign {
+		mutators = append(mutators, Resign(builder))
+	}
+
+	if b.CorruptSignature {
+		mutators = append(mutators, CorruptSignature())
+	}
+
+	if b.DropPayBidTx {
+		mutators = append(mutators, DropPayBidTx())
+	}
+
+	if b.PayBidTx != nil {
+		mutators = append(mutators, WithPayBidTx(*b.PayBidTx))
+	}
+
+	if b.PayBidTxGasUsed != nil {
+		mutators = append(mutators, WithPayBidTxGasUsed(*b.PayBidTxGasUsed))
+	}
+
+	return mutators
+}
+
+func (s *Scenario) assert(arg *BidCaseArg, bidArgs *types.BidArgs, txs types.Transactions) (bool, error) {
+	switch s.Expect {
+	case ExpectTxsSucceed:
+		return assertTxSucceed(arg, bidArgs, txs)
+	case ExpectNoError:
+		return assertNoError(arg, bidArgs, txs)
+	case ExpectNotSealed:
+		return assertNotSealed(arg, bidArgs)
+	case ExpectInvalidBidParam:
+		return assertErrorCode(arg, bidArgs, types.InvalidBidParamError)
+	case ExpectInvalidPayBidTx:
+		return assertErrorCode(arg, bidArgs, types.InvalidPayBidTxError)
+	default:
+		return assertErrorCode(arg, bidArgs, s.ErrorCode)
+	}
+}
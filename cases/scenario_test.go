@@ -0,0 +1,142 @@
+package cases
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadScenarios(t *testing.T) {
+	scenarios, err := LoadScenarios("testdata/scenarios/invalid.yaml")
+	assert.Nil(t, err)
+	// one scenario of each InvalidBid_* case
+	assert.Equal(t, len(Cases(TagInvalid)), len(scenarios))
+
+	byName := make(map[string]*Scenario)
+	for _, s := range scenarios {
+		byName[s.Name] = s
+	}
+
+	s := byName["Scenario_LessGasFee_20"]
+	assert.Equal(t, AccountingEstimated, s.accounting())
+	assert.Equal(t, big.NewInt(420000000000000), s.Bid.GasFeeMultiplier.mul(big.NewInt(42000000000000000)))
+
+	s = byName["Scenario_InvalidParentHash_20"]
+	assert.Equal(t, common.Hash{}, *s.Bid.ParentHash)
+
+	s = byName["Scenario_IllegalTxs_3"]
+	assert.Equal(t, TxTypeUnsigned, s.Txs.txType())
+	assert.Equal(t, AccountingEstimated, s.accounting())
+
+	scenarios, err = LoadScenarios("testdata/scenarios/valid.json")
+	assert.Nil(t, err)
+	assert.Len(t, scenarios, 3)
+	assert.Equal(t, big.NewInt(1e16), scenarios[0].Txs.Amount)
+	assert.Equal(t, []Tag{TagScenario, TagPayBid}, scenarios[0].CaseInfo().Tags)
+	assert.Equal(t, big.NewInt(21), scenarios[2].Bid.GasFeeMultiplier.mul(big.NewInt(42)))
+	assert.Equal(t, types.InvalidBidParamError, scenarios[2].ErrorCode)
+}
+
+func TestLoadScenariosInvalid(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"unknown field":     `[{"name": "a", "expect": "no-error", "bid": {"gasFeeMultipler": 2}}]`,
+		"unknown outcome":   `[{"name": "a", "expect": "sealed"}]`,
+		"no error code":     `[{"name": "a", "expect": "error-code"}]`,
+		"simulate unsigned": `[{"name": "a", "expect": "no-error", "txs": {"type": "unsigned"}, "bid": {"accounting": "simulated"}}]`,
+		"abc dynamic fee":   `[{"name": "a", "expect": "no-error", "txs": {"token": "abc", "type": "dynamic-fee"}}]`,
+		"no name":           `[{"expect": "no-error"}]`,
+		"bad multiplier":    `[{"name": "a", "expect": "no-error", "bid": {"gasFeeMultiplier": "half"}}]`,
+	} {
+		path := filepath.Join(dir, "scenario.json")
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0o644))
+
+		_, err := LoadScenarios(path)
+		assert.NotNil(t, err, name)
+	}
+
+	_, err := LoadScenarios(filepath.Join(dir, "scenario.toml"))
+	assert.NotNil(t, err)
+}
+
+func TestRegisterScenarios(t *testing.T) {
+	err := RegisterScenarios([]*Scenario{{Name: "InvalidBid_OldBlockNumber_20", Expect: ExpectNoError}})
+	assert.ErrorContains(t, err, "registered twice")
+
+	err = RegisterScenarios([]*Scenario{
+		{Name: "Scenario_Twice", Expect: ExpectNoError},
+		{Name: "Scenario_Twice", Expect: ExpectNoError},
+	})
+	assert.ErrorContains(t, err, "registered twice")
+
+	_, err = Lookup("Scenario_Twice")
+	assert.NotNil(t, err)
+}
+
+func TestInvalidScenarios(t *testing.T) {
+	arg, server := newTestArg(t, 0)
+
+	scenarios, err := LoadScenarios("testdata/scenarios/invalid.yaml")
+	assert.Nil(t, err)
+
+	for _, s := range scenarios {
+		// waits for the block of the bid, see TestNotSealedScenarios
+		if s.Expect == ExpectNotSealed {
+			continue
+		}
+
+		// a validator accepts at most 3 bids from a builder for a block
+		_, err := server.Chain().Seal()
+		assert.Nil(t, err)
+
+		res := runCaseFn(arg, s.Name, s.CaseInfo().Fn)
+		assert.Equal(t, StatusPassed, res.Status, "%s: %s", res.Name, res.Error)
+		assert.NotEqual(t, common.Hash{}, res.BidHash, s.Name)
+	}
+}
+
+func TestNotSealedScenarios(t *testing.T) {
+	arg, server := newTestArg(t, 200*time.Millisecond)
+
+	scenarios, err := LoadScenarios("testdata/scenarios/invalid.yaml")
+	assert.Nil(t, err)
+
+	for _, s := range scenarios {
+		if s.Expect != ExpectNotSealed {
+			continue
+		}
+
+		res := runCase(arg, s.CaseInfo())
+		assert.Equal(t, StatusPassed, res.Status, "%s: %s", res.Name, res.Error)
+
+		issues := server.Issues()
+		if assert.NotEmpty(t, issues, s.Name) {
+			assert.Equal(t, res.BidHash, issues[len(issues)-1].BidHash, s.Name)
+		}
+	}
+}
+
+func TestValidScenarios(t *testing.T) {
+	if testing.Short() {
+		t.Skip("valid scenarios wait for receipts")
+	}
+
+	arg, server := newTestArg(t, 500*time.Millisecond)
+
+	scenarios, err := LoadScenarios("testdata/scenarios/valid.json")
+	assert.Nil(t, err)
+
+	for _, s := range scenarios {
+		res := runCase(arg, s.CaseInfo())
+		assert.Equal(t, StatusPassed, res.Status, "%s: %s", res.Name, res.Error)
+	}
+
+	assert.Empty(t, server.Issues())
+}
@@ -0,0 +1,319 @@
+package cases
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"github.com/bnb-chain/bsc-mev-cases/utils"
+)
+
+// BidSigner signs the bids of a builder.
+type BidSigner interface {
+	Address() common.Address
+	// SignBid signs the hash of the raw bid, the signature is in the [R || S || V] format of crypto.Sign
+	SignBid(rawBid *types.RawBid) ([]byte, error)
+	// SignPayBidTx signs the PayBidTx of a bid by the latest signer of the chain
+	SignPayBidTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// Signer signs the txs and bids of an account.
+type Signer interface {
+	BidSigner
+	// SignTx signs the tx by the latest signer of the chain
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// BidContentType is the content type of account_signData signing a raw bid, the data is the rlp
+// of the raw bid and the signer signs its keccak256 hash.
+const BidContentType = "application/x-bsc-bid"
+
+// remoteSignerTimeout is the deadline of each request to a remote signer.
+const remoteSignerTimeout = 10 * time.Second
+
+// bidHash returns the hash of the raw bid signed by the builder.
+func bidHash(rawBid *types.RawBid) ([]byte, error) {
+	data, err := rlp.EncodeToBytes(rawBid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode raw bid, %v", err)
+	}
+
+	return crypto.Keccak256(data), nil
+}
+
+// SignerSpecUsage describes the specs of LoadSigner in the usage of the key flags.
+const SignerSpecUsage = "keystore:PATH, file:PATH or env:NAME of a hex key, or remote:URL[#ADDRESS] of a clef-style signer"
+
+// LoadSigner creates the signer of a spec, keystore:PATH, file:PATH or env:NAME of
+// utils.LoadPrivateKey, or remote:URL[#ADDRESS] of an external signer, the address is
+// needed if the signer has more than one account.
+func LoadSigner(ctx context.Context, spec string, password utils.KeyPassword) (Signer, error) {
+	kind, location, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "keystore":
+		pass, err := password.Read()
+		if err != nil {
+			return nil, err
+		}
+		return NewKeystoreSigner(location, pass)
+	case "remote":
+		url, address, _ := strings.Cut(location, "#")
+		if address != "" && !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid remote signer address %q", address)
+		}
+		return DialRemoteSigner(ctx, url, common.HexToAddress(address))
+	}
+
+	key, err := utils.LoadPrivateKey(spec, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKeySigner(key), nil
+}
+
+// KeySigner signs by a private key in memory.
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeySigner creates the signer of the private key.
+func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+func (s *KeySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *KeySigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+func (s *KeySigner) SignBid(rawBid *types.RawBid) ([]byte, error) {
+	hash, err := bidHash(rawBid)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.Sign(hash, s.key)
+}
+
+func (s *KeySigner) SignPayBidTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return s.SignTx(tx, chainID)
+}
+
+// KeystoreSigner signs by an account of an encrypted keystore, the key stays in the keystore
+// unlocked by the password.
+type KeystoreSigner struct {
+	ks      *keystore.KeyStore
+	account accounts.Account
+}
+
+// NewKeystoreSigner unlocks the account of the keystore file by the password.
+func NewKeystoreSigner(keystoreFile, password string) (*KeystoreSigner, error) {
+	path, err := filepath.Abs(keystoreFile)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := keystore.NewKeyStore(filepath.Dir(path), keystore.StandardScryptN, keystore.StandardScryptP)
+	account, err := ks.Find(accounts.Account{URL: accounts.URL{Scheme: keystore.KeyStoreScheme, Path: path}})
+	if err != nil {
+		return nil, fmt.Errorf("keystore %v, %v", keystoreFile, err)
+	}
+
+	if err = ks.Unlock(account, password); err != nil {
+		return nil, fmt.Errorf("failed to unlock keystore %v, %v", keystoreFile, err)
+	}
+
+	return &KeystoreSigner{ks: ks, account: account}, nil
+}
+
+func (s *KeystoreSigner) Address() common.Address {
+	return s.account.Address
+}
+
+func (s *KeystoreSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return s.ks.SignTx(s.account, tx, chainID)
+}
+
+func (s *KeystoreSigner) SignBid(rawBid *types.RawBid) ([]byte, error) {
+	hash, err := bidHash(rawBid)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ks.SignHash(s.account, hash)
+}
+
+func (s *KeystoreSigner) SignPayBidTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return s.SignTx(tx, chainID)
+}
+
+// RemoteSigner signs by an external signer over json-rpc in the api of clef, bids by
+// account_signData of BidContentType and txs by account_signTransaction.
+type RemoteSigner struct {
+	client  *rpc.Client
+	address common.Address
+}
+
+// DialRemoteSigner connects to the external signer at url signing by the account of the address,
+// the only account of the signer is used if the address is zero.
+func DialRemoteSigner(ctx context.Context, url string, address common.Address) (*RemoteSigner, error) {
+	client, err := rpc.DialOptions(ctx, url, rpc.WithHTTPClient(utils.Client))
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []common.Address
+	if err = client.CallContext(ctx, &addresses, "account_list"); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("account_list of remote signer, %v", err)
+	}
+
+	found := false
+	for _, a := range addresses {
+		found = found || a == address
+	}
+
+	switch {
+	case address == (common.Address{}) && len(addresses) == 1:
+		address = addresses[0]
+	case address == (common.Address{}):
+		client.Close()
+		return nil, fmt.Errorf("remote signer has %v accounts, an address is needed", len(addresses))
+	case !found:
+		client.Close()
+		return nil, fmt.Errorf("remote signer has no account %v", address)
+	}
+
+	return &RemoteSigner{client: client, address: address}, nil
+}
+
+// Close disconnects from the signer.
+func (s *RemoteSigner) Close() {
+	s.client.Close()
+}
+
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *RemoteSigner) SignBid(rawBid *types.RawBid) ([]byte, error) {
+	data, err := rlp.EncodeToBytes(rawBid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode raw bid, %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteSignerTimeout)
+	defer cancel()
+
+	var sig hexutil.Bytes
+	err = s.client.CallContext(ctx, &sig, "account_signData", BidContentType,
+		common.NewMixedcaseAddress(s.address), hexutil.Bytes(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("remote signature of %v bytes", len(sig))
+	}
+
+	// clef signs some content types with V of 27/28
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	// the signer must sign the bid as sent, by the account
+	pub, err := crypto.SigToPub(crypto.Keccak256(data), sig)
+	if err != nil || crypto.PubkeyToAddress(*pub) != s.address {
+		return nil, fmt.Errorf("remote signed bid not signed by %v", s.address)
+	}
+
+	return sig, nil
+}
+
+func (s *RemoteSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteSignerTimeout)
+	defer cancel()
+
+	var res struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := s.client.CallContext(ctx, &res, "account_signTransaction", sendTxArgs(tx, s.address, chainID)); err != nil {
+		return nil, err
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(res.Raw); err != nil {
+		return nil, fmt.Errorf("invalid remote signed tx, %v", err)
+	}
+
+	// the signer must sign the tx as sent, by the account
+	signer := types.LatestSignerForChainID(chainID)
+	if signer.Hash(signed) != signer.Hash(tx) {
+		return nil, errors.New("remote signed tx differs from the tx sent")
+	}
+
+	from, err := types.Sender(signer, signed)
+	if err != nil || from != s.address {
+		return nil, fmt.Errorf("remote signed tx not signed by %v", s.address)
+	}
+
+	return signed, nil
+}
+
+func (s *RemoteSigner) SignPayBidTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return s.SignTx(tx, chainID)
+}
+
+// sendTxArgs returns the args of account_signTransaction of the tx.
+func sendTxArgs(tx *types.Transaction, from common.Address, chainID *big.Int) *apitypes.SendTxArgs {
+	input := hexutil.Bytes(tx.Data())
+	args := &apitypes.SendTxArgs{
+		From:    common.NewMixedcaseAddress(from),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Input:   &input,
+		ChainID: (*hexutil.Big)(chainID),
+	}
+
+	if tx.To() != nil {
+		to := common.NewMixedcaseAddress(*tx.To())
+		args.To = &to
+	}
+
+	switch tx.Type() {
+	case types.DynamicFeeTxType:
+		accessList := tx.AccessList()
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+		args.AccessList = &accessList
+	case types.AccessListTxType:
+		accessList := tx.AccessList()
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+		args.AccessList = &accessList
+	default:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	}
+
+	return args
+}
@@ -0,0 +1,104 @@
+package cases
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/bsc-mev-cases/mevtest"
+	"github.com/bnb-chain/bsc-mev-cases/utils"
+)
+
+func TestSigners(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.Nil(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	ks := keystore.NewKeyStore(filepath.Join(t.TempDir(), "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(key, "secret")
+	assert.Nil(t, err)
+	t.Setenv("TEST_PASSWORD", "secret")
+
+	other, err := crypto.GenerateKey()
+	assert.Nil(t, err)
+	server := mevtest.NewSignerServer(other, key)
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	password := utils.KeyPassword{Env: "TEST_PASSWORD"}
+
+	keystoreSigner, err := LoadSigner(ctx, "keystore:"+account.URL.Path, password)
+	assert.Nil(t, err)
+	remoteSigner, err := LoadSigner(ctx, "remote:"+server.URL+"#"+address.Hex(), password)
+	assert.Nil(t, err)
+
+	chainID := big.NewInt(56)
+	rawBid := &types.RawBid{
+		BlockNumber: 1,
+		ParentHash:  common.HexToHash("0x01"),
+		GasUsed:     21000,
+		GasFee:      big.NewInt(1e15),
+		BuilderFee:  big.NewInt(1e14),
+	}
+
+	local := newAccount(NewKeySigner(key), nil)
+	bidArgs, err := local.SignBid(rawBid)
+	assert.Nil(t, err)
+	payBidTx, err := local.PayBidTx(1, common.HexToAddress("0x02"), chainID, big.NewInt(1e14))
+	assert.Nil(t, err)
+	transfer, err := local.TransferBNBWithOptions(1, common.HexToAddress("0x03"), chainID, big.NewInt(1),
+		TxOptions{Type: types.DynamicFeeTxType, GasPrice: DefaultBNBGasPrice, GasTipCap: DefaultBNBGasPrice})
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyBidSignature(bidArgs, address))
+
+	// the signatures of crypto.Sign are deterministic, all the signers sign the same
+	for _, signer := range []Signer{keystoreSigner, remoteSigner} {
+		acc := newAccount(signer, nil)
+		assert.Equal(t, address, acc.Address)
+		signed, err := acc.SignBid(rawBid)
+		assert.Nil(t, err)
+		assert.Equal(t, bidArgs.Signature, signed.Signature)
+		signedPayBidTx, err := acc.PayBidTx(1, common.HexToAddress("0x02"), chainID, big.NewInt(1e14))
+		assert.Nil(t, err)
+		assert.Equal(t, payBidTx, signedPayBidTx)
+
+		tx, err := acc.TransferBNBWithOptions(1, common.HexToAddress("0x03"), chainID, big.NewInt(1),
+			TxOptions{Type: types.DynamicFeeTxType, GasPrice: DefaultBNBGasPrice, GasTipCap: DefaultBNBGasPrice})
+		assert.Nil(t, err)
+		assert.Equal(t, transfer.Hash(), tx.Hash())
+	}
+
+	assert.Equal(t, 1, server.Requests("account_signData"))
+	assert.Equal(t, 2, server.Requests("account_signTransaction"))
+
+	// the address is needed if the signer has more than one account
+	_, err = DialRemoteSigner(ctx, server.URL, common.Address{})
+	assert.ErrorContains(t, err, "an address is needed")
+	_, err = DialRemoteSigner(ctx, server.URL, common.HexToAddress("0x04"))
+	assert.ErrorContains(t, err, "no account")
+
+	_, err = LoadSigner(ctx, "keystore:"+account.URL.Path, utils.KeyPassword{})
+	assert.NotNil(t, err)
+
+	// a remote signature of another key is rejected
+	server.SetKey(address, other)
+	_, err = remoteSigner.SignBid(rawBid)
+	assert.ErrorContains(t, err, "not signed by")
+	_, err = remoteSigner.SignPayBidTx(types.NewTx(&types.LegacyTx{Nonce: 1, Gas: 25000}), chainID)
+	assert.ErrorContains(t, err, "not signed by")
+
+	// the errors of the signer are returned
+	acc := newAccount(remoteSigner, nil)
+	server.Close()
+	_, err = acc.SignBid(rawBid)
+	assert.ErrorContains(t, err, "failed to sign raw bid")
+	_, err = acc.PayBidTx(1, common.HexToAddress("0x02"), chainID, big.NewInt(1e14))
+	assert.ErrorContains(t, err, "failed to sign PayBidTx")
+}
@@ -1,45 +1,318 @@
 package cases
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/bnb-chain/bsc-mev-cases/log"
 )
 
-var (
-	stableCases = map[string]BidCaseFn{
-		"ValidBid_NilPayBidTx_500":     ValidBid_NilPayBidTx_500,
-		"InvalidBid_OldBlockNumber_20": InvalidBid_OldBlockNumber_20,
-		"InvalidBid_IllegalTxs_20":     InvalidBid_IllegalTxs_20,
-		"InvalidBid_GasExceed_10000":   InvalidBid_GasExceed_10000,
-	}
+const (
+	// DefaultStableDuration is the duration of a stable run if not configured
+	DefaultStableDuration = 8 * time.Hour
+	// DefaultStableInterval is the interval between the cases of a stable run if not configured
+	DefaultStableInterval = 500 * time.Millisecond
 )
 
-// RunStableCases runs stable cases for 8h
-func RunStableCases(arg *BidCaseArg) {
-	ticker := time.NewTicker(500 * time.Millisecond)
+// StableConfig configures a stable run.
+type StableConfig struct {
+	// Duration is how long the cases are run, DefaultStableDuration if 0, the time before a
+	// restart counts for a resumed run
+	Duration time.Duration
+	// Interval is the interval between the cases, DefaultStableInterval if 0
+	Interval time.Duration
+	// Weights are the relative frequencies of the stable cases by name, 1 if absent, a case of
+	// weight 0 is not run
+	Weights map[string]int
+	// Seed picks the cases, the same seed and weights run the cases in the same order
+	Seed int64
+	// StateFile is where the progress and statistics are saved after each case, not saved if empty
+	StateFile string
+	// Resume continues the run saved in StateFile, which is started over if the file does not exist
+	Resume bool
+}
+
+// ParseCaseWeights parses comma separated name:weight, e.g. ValidBid_NilPayBidTx_1:3,InvalidBid_NilNumber_20:0.
+func ParseCaseWeights(s string) (map[string]int, error) {
+	weights := make(map[string]int)
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		name, weight, found := strings.Cut(field, ":")
+		w, err := strconv.Atoi(weight)
+		if !found || err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid case weight %q", field)
+		}
+
+		weights[name] = w
+	}
+
+	return weights, nil
+}
+
+// StableCaseStats is the statistics of a case of a stable run.
+type StableCaseStats struct {
+	Runs      int           `json:"runs"`
+	Passed    int           `json:"passed"`
+	Failed    int           `json:"failed"`
+	Timeouts  int           `json:"timeouts"`
+	Duration  time.Duration `json:"durationNs"`
+	LastError string        `json:"lastError,omitempty"`
+}
+
+// StableState is the progress of a stable run saved to StateFile, a resumed run continues from it.
+type StableState struct {
+	Seed    int64          `json:"seed"`
+	Weights map[string]int `json:"weights"`
+	// Iterations is the number of cases run
+	Iterations int `json:"iterations"`
+	// Elapsed is the time the run has taken, not counting the time between restarts
+	Elapsed   time.Duration               `json:"elapsedNs"`
+	Cases     map[string]*StableCaseStats `json:"cases"`
+	UpdatedAt time.Time                   `json:"updatedAt"`
+}
+
+// LoadStableState reads the state saved by a stable run.
+func LoadStableState(path string) (*StableState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	state := new(StableState)
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to decode stable state %v, %v", path, err)
+	}
+
+	if state.Cases == nil {
+		state.Cases = make(map[string]*StableCaseStats)
+	}
+
+	return state, nil
+}
+
+// save writes the state to a temp file renamed to the path, so a crash leaves the previous state.
+func (s *StableState) save(path string) error {
+	s.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *StableState) observe(res *CaseResult) {
+	stats, ok := s.Cases[res.Name]
+	if !ok {
+		stats = new(StableCaseStats)
+		s.Cases[res.Name] = stats
+	}
+
+	stats.Runs++
+	stats.Duration += res.Duration
+	switch res.Status {
+	case StatusPassed:
+		stats.Passed++
+	case StatusTimeout:
+		stats.Timeouts++
+		stats.LastError = res.Error
+	default:
+		stats.Failed++
+		stats.LastError = res.Error
+	}
+
+	s.Iterations++
+}
+
+// results returns a result of each case run by name, failed if any of its runs failed.
+func (s *StableState) results() []*CaseResult {
+	names := make([]string, 0, len(s.Cases))
+	for name := range s.Cases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]*CaseResult, 0, len(names))
+	for _, name := range names {
+		stats := s.Cases[name]
+		res := &CaseResult{Name: name, Status: StatusPassed, Duration: stats.Duration}
+		if failures := stats.Failed + stats.Timeouts; failures > 0 {
+			res.Status = StatusFailed
+			if stats.Failed == 0 {
+				res.Status = StatusTimeout
+			}
+			res.Error = fmt.Sprintf("%v of %v runs failed, last error: %v", failures, stats.Runs, stats.LastError)
+		}
+
+		results = append(results, res)
+	}
+
+	return results
+}
+
+// stableSchedule picks the stable cases by weight in the order determined by the seed.
+type stableSchedule struct {
+	rand    *rand.Rand
+	cases   []*CaseInfo
+	weights []int
+	total   int
+}
+
+func newStableSchedule(seed int64, weights map[string]int) (*stableSchedule, error) {
+	s := &stableSchedule{rand: rand.New(rand.NewSource(seed))}
+
+	stable := make(map[string]bool)
+	for _, c := range Cases(TagStable) {
+		stable[c.Name] = true
+
+		w, ok := weights[c.Name]
+		if !ok {
+			w = 1
+		}
+		if w == 0 {
+			continue
+		}
+
+		s.cases = append(s.cases, c)
+		s.weights = append(s.weights, w)
+		s.total += w
+	}
+
+	for name := range weights {
+		if !stable[name] {
+			return nil, fmt.Errorf("weight of case %s not tagged stable", name)
+		}
+	}
+
+	if s.total == 0 {
+		return nil, errors.New("no stable case to run")
+	}
+
+	return s, nil
+}
+
+func (s *stableSchedule) next() *CaseInfo {
+	n := s.rand.Intn(s.total)
+	for i, w := range s.weights {
+		if n < w {
+			return s.cases[i]
+		}
+		n -= w
+	}
+
+	return s.cases[len(s.cases)-1]
+}
+
+// RunStableCases runs the cases tagged stable picked by weight one at a time, until the duration
+// of the config is elapsed. The progress is saved to StateFile after each case, so a run can be
+// resumed after a restart with the same sequence of cases. Only the statistics of the cases are
+// kept, a result is returned for each case run, summing up its runs before the restart too.
+func RunStableCases(arg *BidCaseArg, config StableConfig) ([]*CaseResult, error) {
+	if config.Duration == 0 {
+		config.Duration = DefaultStableDuration
+	}
+	if config.Interval == 0 {
+		config.Interval = DefaultStableInterval
+	}
+
+	state, err := stableState(config)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := newStableSchedule(state.Seed, state.Weights)
+	if err != nil {
+		return nil, err
+	}
+
+	// the cases run before the restart are skipped in the same order
+	for i := 0; i < state.Iterations; i++ {
+		schedule.next()
+	}
+
+	log.Infow("stable run", "seed", state.Seed, "iterations", state.Iterations, "elapsed", state.Elapsed,
+		"duration", config.Duration)
+
+	ticker := time.NewTicker(config.Interval)
 	defer ticker.Stop()
-	counter := 0
 
-	for {
+	last := time.Now()
+	for state.Elapsed < config.Duration {
 		select {
+		case <-arg.Ctx.Done():
+			return state.results(), arg.Ctx.Err()
 		case <-ticker.C:
-			runStableCases(arg)
-			counter++
-			if counter > 57600 {
-				println("stable test done")
-				return
+		}
+
+		c := schedule.next()
+		res := runCaseFn(arg, c.Name, inTurnFn(c.Fn))
+		if res.Failed() {
+			println("stable case failed, ", "case ", c.Name, " err ", res.Error)
+		} else {
+			println("stable case succeed, ", "case ", c.Name)
+		}
+
+		now := time.Now()
+		state.Elapsed += now.Sub(last)
+		last = now
+
+		state.observe(res)
+		if config.StateFile != "" {
+			if err = state.save(config.StateFile); err != nil {
+				log.Errorw("failed to save stable state", "err", err)
 			}
 		}
 	}
+
+	println("stable test done")
+	return state.results(), nil
 }
 
-func runStableCases(arg *BidCaseArg) {
-	for n, c := range stableCases {
-		waitForInTurn(arg)
-		err := c(arg)
-		if err != nil {
-			println("stable case failed, ", "case ", n, " err ", err.Error())
-		} else {
-			println("stable case succeed, ", "case ", n)
+// stableState returns the state resumed from StateFile, or a new state of the config.
+func stableState(config StableConfig) (*StableState, error) {
+	if config.Resume && config.StateFile != "" {
+		state, err := LoadStableState(config.StateFile)
+		if err == nil {
+			return state, nil
 		}
+
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		log.Infow("no stable state to resume, start over", "path", config.StateFile)
 	}
+
+	return &StableState{
+		Seed:    config.Seed,
+		Weights: config.Weights,
+		Cases:   make(map[string]*StableCaseStats),
+	}, nil
 }
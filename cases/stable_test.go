@@ -0,0 +1,114 @@
+package cases
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCaseWeights(t *testing.T) {
+	weights, err := ParseCaseWeights("InvalidBid_OldBlockNumber_20:3, ValidBid_NilPayBidTx_500:0")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"InvalidBid_OldBlockNumber_20": 3, "ValidBid_NilPayBidTx_500": 0}, weights)
+
+	for _, s := range []string{"InvalidBid_OldBlockNumber_20", "InvalidBid_OldBlockNumber_20:-1", "a:b"} {
+		_, err = ParseCaseWeights(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestStableSchedule(t *testing.T) {
+	weights := map[string]int{"ValidBid_NilPayBidTx_500": 0, "InvalidBid_OldBlockNumber_20": 3}
+
+	a, err := newStableSchedule(1, weights)
+	assert.Nil(t, err)
+	b, err := newStableSchedule(1, weights)
+	assert.Nil(t, err)
+
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		c := a.next()
+		assert.Equal(t, c.Name, b.next().Name)
+		counts[c.Name]++
+	}
+
+	assert.Zero(t, counts["ValidBid_NilPayBidTx_500"])
+	assert.Greater(t, counts["InvalidBid_OldBlockNumber_20"], 2*counts["InvalidBid_IllegalTxs_20"])
+
+	_, err = newStableSchedule(1, map[string]int{"ValidBid_NilPayBidTx_1": 1})
+	assert.ErrorContains(t, err, "not tagged stable")
+}
+
+func TestRunStableCasesResume(t *testing.T) {
+	arg, _ := newTestArg(t, 200*time.Millisecond)
+	path := filepath.Join(t.TempDir(), "stable.json")
+
+	config := StableConfig{
+		Duration:  time.Second,
+		Interval:  10 * time.Millisecond,
+		Weights:   map[string]int{"ValidBid_NilPayBidTx_500": 0, "InvalidBid_GasExceed_10000": 0},
+		Seed:      7,
+		StateFile: path,
+	}
+
+	first, err := RunStableCases(arg, config)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, first)
+
+	state, err := LoadStableState(path)
+	assert.Nil(t, err)
+	assert.Equal(t, len(state.Cases), len(first))
+	assert.GreaterOrEqual(t, state.Elapsed, time.Second)
+	iterations := state.Iterations
+
+	// resumed with the seed and weights of the state, until the longer duration
+	config.Duration, config.Seed, config.Weights, config.Resume = state.Elapsed+time.Second, 8, nil, true
+	second, err := RunStableCases(arg, config)
+	assert.Nil(t, err)
+	// a result of each case, the runs before the restart included
+	assert.Equal(t, len(state.Cases), len(second))
+
+	state, err = LoadStableState(path)
+	assert.Nil(t, err)
+	assert.Greater(t, state.Iterations, iterations)
+
+	runs := 0
+	for _, stats := range state.Cases {
+		runs += stats.Runs
+		assert.Equal(t, stats.Runs, stats.Passed+stats.Failed+stats.Timeouts)
+	}
+	assert.Equal(t, state.Iterations, runs)
+
+	// the same cases as a run without restart
+	schedule, err := newStableSchedule(7, map[string]int{"ValidBid_NilPayBidTx_500": 0, "InvalidBid_GasExceed_10000": 0})
+	assert.Nil(t, err)
+	counts := make(map[string]int)
+	for i := 0; i < state.Iterations; i++ {
+		counts[schedule.next().Name]++
+	}
+	for _, res := range second {
+		assert.Equal(t, counts[res.Name], state.Cases[res.Name].Runs, res.Name)
+	}
+}
+
+func TestStableStateResults(t *testing.T) {
+	state := &StableState{Cases: make(map[string]*StableCaseStats)}
+	for _, res := range []*CaseResult{
+		{Name: "b", Status: StatusPassed},
+		{Name: "a", Status: StatusTimeout, Error: "deadline"},
+		{Name: "b", Status: StatusFailed, Error: "boom"},
+		{Name: "c", Status: StatusPassed},
+	} {
+		state.observe(res)
+	}
+
+	results := state.results()
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, StatusTimeout, results[0].Status)
+		assert.Equal(t, StatusFailed, results[1].Status)
+		assert.Equal(t, "1 of 2 runs failed, last error: boom", results[1].Error)
+		assert.Equal(t, StatusPassed, results[2].Status)
+	}
+}
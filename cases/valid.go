@@ -1,91 +1,114 @@
 package cases
 
 import (
-	"context"
-	"errors"
 	"fmt"
 	"math/big"
-	"time"
 
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/bnb-chain/bsc-mev-cases/log"
 )
 
-var (
-	validBidCases = map[string]BidCaseFn{
-		"ValidBid_NilPayBidTx_200": ValidBid_NilPayBidTx_200,
-		"ValidBid_NilPayBidTx_500": ValidBid_NilPayBidTx_500,
-		"ValidBid_PayBidTx_200":    ValidBid_PayBidTx_200,
-	}
-)
+func init() {
+	Register(&CaseInfo{
+		Name:          "ValidBid_NilPayBidTx_200",
+		Description:   "bid of 200 BNB transfers without PayBidTx",
+		Tags:          []Tag{TagValid},
+		Expect:        ExpectTxsSucceed,
+		EstimatedCost: 0.42,
+		Fn:            ValidBid_NilPayBidTx_200,
+	})
+	Register(&CaseInfo{
+		Name:          "ValidBid_NilPayBidTx_500",
+		Description:   "bid of 500 BNB transfers without PayBidTx",
+		Tags:          []Tag{TagValid, TagStable},
+		Expect:        ExpectTxsSucceed,
+		EstimatedCost: 1.05,
+		Fn:            ValidBid_NilPayBidTx_500,
+	})
+	Register(&CaseInfo{
+		Name:          "ValidBid_PayBidTx_200",
+		Description:   "bid of 200 BNB transfers paying builder fee by PayBidTx",
+		Tags:          []Tag{TagValid, TagPayBid},
+		Expect:        ExpectTxsSucceed,
+		EstimatedCost: 0.4205,
+		Fn:            ValidBid_PayBidTx_200,
+	})
+	Register(&CaseInfo{
+		Name:          "ValidBid_MixedTxTypes_30",
+		Description:   "bid of 30 legacy, access list and dynamic fee BNB transfers",
+		Tags:          []Tag{TagValid, TagTxTypes},
+		Expect:        ExpectTxsSucceed,
+		EstimatedCost: 0.0654,
+		Fn:            ValidBid_MixedTxTypes_30,
+	})
+}
 
-func RunValidCases(arg *BidCaseArg) {
-	for n, c := range validBidCases {
-		waitForInTurn(arg)
-		print("run case ", n)
-		err := c(arg)
-		if err != nil {
-			print(" failed: ", err.Error())
-		} else {
-			print(" succeed")
-		}
-		println()
-	}
+// RunValidCases runs the cases tagged valid.
+func RunValidCases(arg *BidCaseArg) []*CaseResult {
+	return RunCases(arg, TagValid)
 }
 
-func RunCase(arg *BidCaseArg, name string) {
-	caseFn, err := getCaseFn(name)
-	if err != nil {
-		println(err.Error())
-		return
+// RunCases runs the cases tagged with any of the given tags.
+func RunCases(arg *BidCaseArg, tags ...Tag) []*CaseResult {
+	results := make([]*CaseResult, 0)
+	for _, c := range Cases(tags...) {
+		results = append(results, runCase(arg, c))
 	}
 
-	waitForInTurn(arg)
-	print("run case ", name)
-	err = caseFn(arg)
+	return results
+}
+
+// RunCase runs the case registered with the name.
+func RunCase(arg *BidCaseArg, name string) []*CaseResult {
+	c, err := Lookup(name)
 	if err != nil {
-		print(" failed: ", err.Error())
-	} else {
-		print(" succeed")
+		println(err.Error())
+		return []*CaseResult{{Name: name, Status: StatusFailed, Error: err.Error()}}
 	}
 
-	println()
+	return []*CaseResult{runCase(arg, c)}
 }
 
-func getCaseFn(name string) (BidCaseFn, error) {
-	c, ok := validBidCases[name]
-	if ok {
-		return c, nil
-	}
-
-	c, ok = invalidBidCases[name]
-	if ok {
-		return c, nil
+func runCase(arg *BidCaseArg, c *CaseInfo) *CaseResult {
+	fn := c.Fn
+	if !c.HasTag(TagQuery) {
+		fn = inTurnFn(fn)
 	}
 
-	c, ok = abcCases[name]
-	if ok {
-		return c, nil
+	print("run case ", c.Name)
+	res := runCaseFn(arg, c.Name, fn)
+	if res.Failed() {
+		print(" failed: ", res.Error)
+	} else {
+		print(" succeed")
 	}
 
-	return nil, errors.New("case fn not found")
+	println()
+	return res
 }
 
 // ValidBid_NilPayBidTx_1
 // gasFee = 21000 * 1 * 0.0000001 BNB = 0.42/200 BNB
 func ValidBid_NilPayBidTx_1(arg *BidCaseArg) error {
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 1)
-	gasUsed := BNBGasUsed * 1
-	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	acc, err := simulateBid(arg, txs)
+	if err != nil {
+		return err
+	}
+	gasUsed, gasFee := int64(acc.GasUsed()), acc.GasFee()
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertTxSucceed(arg.Ctx, arg.Client, bidArgs, txs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		retry, err = assertTxSucceed(arg.Ctx, arg.Client, bidArgs, txs)
+	retry, err := assertTxSucceed(arg, bidArgs, txs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertTxSucceed(arg, bidArgs, txs)
 	}
 	return err
 }
@@ -94,14 +117,22 @@ func ValidBid_NilPayBidTx_1(arg *BidCaseArg) error {
 // gasFee = 21000 * 200 * 0.0000001 BNB = 0.42 BNB
 func ValidBid_NilPayBidTx_200(arg *BidCaseArg) error {
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 200)
-	gasUsed := BNBGasUsed * 200
-	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	acc, err := simulateBid(arg, txs)
+	if err != nil {
+		return err
+	}
+	gasUsed, gasFee := int64(acc.GasUsed()), acc.GasFee()
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertTxSucceed(arg.Ctx, arg.Client, bidArgs, txs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		retry, err = assertTxSucceed(arg.Ctx, arg.Client, bidArgs, txs)
+	retry, err := assertTxSucceed(arg, bidArgs, txs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertTxSucceed(arg, bidArgs, txs)
 	}
 	return err
 }
@@ -110,14 +141,23 @@ func ValidBid_NilPayBidTx_200(arg *BidCaseArg) error {
 // gasFee = 21000 * 500 * 0.0000001 BNB = 1.05 BNB
 func ValidBid_NilPayBidTx_500(arg *BidCaseArg) error {
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 500)
-	gasUsed := BNBGasUsed * 500
-	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
+	acc, err := simulateBid(arg, txs)
+	if err != nil {
+		return err
+	}
+	gasUsed, gasFee := int64(acc.GasUsed()), acc.GasFee()
 
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-	retry, err := assertTxSucceed(arg.Ctx, arg.Client, bidArgs, txs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
-		retry, err = assertTxSucceed(arg.Ctx, arg.Client, bidArgs, txs)
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
+
+	retry, err := assertTxSucceed(arg, bidArgs, txs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertTxSucceed(arg, bidArgs, txs)
 	}
 	return err
 }
@@ -127,23 +167,61 @@ func ValidBid_NilPayBidTx_500(arg *BidCaseArg) error {
 // builderFee = 0.05 BNB
 func ValidBid_PayBidTx_200(arg *BidCaseArg) error {
 	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 200)
-	gasUsed := BNBGasUsed * 200
-	gasFee := big.NewInt(gasUsed * DefaultBNBGasPrice.Int64())
-	bidArgs := generateValidBid(arg, txs, gasUsed, gasFee, true, BuilderFee)
+	acc, err := simulateBid(arg, txs)
+	if err != nil {
+		return err
+	}
+	gasUsed, gasFee := int64(acc.GasUsed()), acc.GasFee()
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, true, BuilderFee)
+	if err != nil {
+		return err
+	}
 
-	retry, err := assertTxSucceed(arg.Ctx, arg.Client, bidArgs, txs)
-	for retry {
-		bidArgs = generateValidBid(arg, txs, gasUsed, gasFee, true, BuilderFee)
-		retry, err = assertTxSucceed(arg.Ctx, arg.Client, bidArgs, txs)
+	retry, err := assertTxSucceed(arg, bidArgs, txs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, true, BuilderFee); err != nil {
+			return err
+		}
+		retry, err = assertTxSucceed(arg, bidArgs, txs)
 	}
 
 	return err
 }
 
+// ValidBid_MixedTxTypes_30
+// 10 legacy, 10 access list and 10 dynamic fee txs, paying the same effective gas price
+// gasFee = (21000 * 30 + 2400 * 10) * 0.0000001 BNB = 0.0654 BNB
+func ValidBid_MixedTxTypes_30(arg *BidCaseArg) error {
+	bob := arg.Bob.Address()
+	txs := GenerateBNBTxsWithOptions(arg, TransferAmountPerTx, 30, MixedTxOptions(bob)...)
+	acc, err := simulateBid(arg, txs)
+	if err != nil {
+		return err
+	}
+	gasUsed, gasFee := int64(acc.GasUsed()), acc.GasFee()
+	bidArgs, err := generateValidBid(arg, txs, gasUsed, gasFee, false, nil)
+	if err != nil {
+		return err
+	}
+
+	retry, err := assertTxSucceed(arg, bidArgs, txs)
+	for retry && arg.canRetry() {
+		if bidArgs, err = generateValidBid(arg, txs, gasUsed, gasFee, false, nil); err != nil {
+			return err
+		}
+		retry, err = assertTxSucceed(arg, bidArgs, txs)
+	}
+	if err != nil {
+		return err
+	}
+
+	return assertGasFee(arg, bidArgs, txs)
+}
+
 func generateBNBFailedTxs(arg *BidCaseArg, txcount int) types.Transactions {
-	bundleFactory := NewBidFactory(arg.Ctx, arg.Client, arg.RootPk, arg.BobPk, arg.Abc)
+	bundleFactory := NewBidFactory(arg.Ctx, arg.FullNode, arg.Nonces, arg.Root, arg.Bob, arg.Abc)
 	root := bundleFactory.Root()
-	balance := root.BalanceBNB(arg.Client)
+	balance := root.BalanceBNB(arg.Ctx, arg.FullNode)
 	balance.Add(balance, TransferAmountPerTx)
 
 	txs := make([]*types.Transaction, 0)
@@ -157,9 +235,9 @@ func generateBNBFailedTxs(arg *BidCaseArg, txcount int) types.Transactions {
 	return txs
 }
 
-func assertTxSucceed(ctx context.Context, client *ethclient.Client, bidArgs *types.BidArgs, txs types.Transactions) (
+func assertTxSucceed(arg *BidCaseArg, bidArgs *types.BidArgs, txs types.Transactions) (
 	bool, error) {
-	_, err := client.SendBid(ctx, *bidArgs)
+	_, err := arg.sendBid(bidArgs)
 	if err != nil {
 		bidErr, ok := err.(rpc.Error)
 		if !ok {
@@ -172,45 +250,92 @@ func assertTxSucceed(ctx context.Context, client *ethclient.Client, bidArgs *typ
 			log.Infow("retry", "reason", "InvalidBidParamError or InvalidPayBidTxError")
 			return true, err
 		}
+
+		// the bid is sent to the validator in turn for its block, which is sealed already
+		if bidErr.ErrorCode() == types.MevNotInTurnError {
+			log.Infow("retry", "reason", "MevNotInTurnError")
+			return true, err
+		}
 	}
 
-	time.Sleep(5 * time.Second)
+	receipts, err := arg.waiter().WaitForBid(arg.Ctx, bidArgs)
+	if err != nil {
+		return false, err
+	}
 
-	for i, tx := range txs {
-		receipt, err := fullNode.TransactionReceipt(ctx, tx.Hash())
-		if err != nil {
-			return false, fmt.Errorf("receipt err, %v", err)
+	if err = assertReceiptsSucceed(receipts); err != nil {
+		return false, err
+	}
+
+	if len(bidArgs.PayBidTx) != 0 {
+		return false, assertBidBalances(arg, bidArgs)
+	}
+
+	return false, nil
+}
+
+// assertReceiptsSucceed checks all the txs of the receipts succeed.
+func assertReceiptsSucceed(receipts []*types.Receipt) error {
+	for i, receipt := range receipts {
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return fmt.Errorf("tx at index %v failed, status %v", i, receipt.Status)
 		}
+	}
+
+	return nil
+}
 
-		if receipt.Status != 1 {
-			return false, fmt.Errorf("tx at index %v failed, %v", i, err)
+// assertGasFee checks the gasFee of the bid is what its txs paid on chain.
+func assertGasFee(arg *BidCaseArg, bidArgs *types.BidArgs, txs types.Transactions) error {
+	paid := big.NewInt(0)
+	for _, tx := range txs {
+		receipt, err := arg.FullNode.TransactionReceipt(arg.Ctx, tx.Hash())
+		if err != nil {
+			return fmt.Errorf("receipt err, %v", err)
 		}
+
+		fee := new(big.Int).SetUint64(receipt.GasUsed)
+		paid.Add(paid, fee.Mul(fee, receipt.EffectiveGasPrice))
 	}
 
-	return false, nil
+	if paid.Cmp(bidArgs.RawBid.GasFee) != 0 {
+		return fmt.Errorf("gasFee %v of bid differs from %v paid by txs", bidArgs.RawBid.GasFee, paid)
+	}
+
+	return nil
 }
 
 // waitForInTurn wait for the current validator in turn
-func waitForInTurn(arg *BidCaseArg) {
-	bidArgs := generateValidBid(arg, nil, 0, big.NewInt(0), false, nil)
+func waitForInTurn(arg *BidCaseArg) error {
+	if arg.ValidatorSet != nil {
+		return waitForValidatorInTurn(arg)
+	}
 
-	inTurn := false
+	bidArgs, err := generateValidBid(arg, nil, 0, big.NewInt(0), false, nil)
+	if err != nil {
+		return err
+	}
 
-	ping := func() {
+	for {
 		_, err := arg.Client.SendBid(arg.Ctx, *bidArgs)
-		if err != nil {
-			bidErr, ok := err.(rpc.Error)
-			if ok && bidErr.ErrorCode() == types.InvalidBidParamError {
-				inTurn = true
-			}
+		if bidErr, ok := err.(rpc.Error); ok && bidErr.ErrorCode() == types.InvalidBidParamError {
+			return nil
+		}
+
+		println("wait for in turn")
+		if err = sleepCtx(arg.Ctx, retryInterval); err != nil {
+			return fmt.Errorf("wait for in turn, %w", err)
 		}
 	}
+}
 
-	ping()
+// inTurnFn waits for the validator in turn before fn, within the deadline of the case.
+func inTurnFn(fn BidCaseFn) BidCaseFn {
+	return func(arg *BidCaseArg) error {
+		if err := waitForInTurn(arg); err != nil {
+			return err
+		}
 
-	for inTurn != true {
-		println("wait for in turn")
-		time.Sleep(500 * time.Millisecond)
-		ping()
+		return fn(arg)
 	}
 }
@@ -0,0 +1,202 @@
+package cases
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/bnb-chain/bsc-mev-cases/log"
+)
+
+// diffInTurn is the difficulty of a block sealed by the validator in turn.
+const diffInTurn = 2
+
+// ValidatorSet is the validators of the chain sealing blocks in turn, and the mev endpoints
+// of the validators under test, e.g. their sentries.
+type ValidatorSet struct {
+	mu sync.RWMutex
+	// validators are ascending, the order they seal blocks in turn
+	validators []common.Address
+	endpoints  map[common.Address]*ethclient.Client
+}
+
+// NewValidatorSet creates the validator set, bids are only sent to the validators with endpoints.
+func NewValidatorSet(validators []common.Address, endpoints map[common.Address]*ethclient.Client) *ValidatorSet {
+	s := &ValidatorSet{endpoints: endpoints}
+	s.Update(validators)
+	return s
+}
+
+// FetchValidators reads the validators of the latest block by parlia_getValidators.
+func FetchValidators(ctx context.Context, client *ethclient.Client) ([]common.Address, error) {
+	var validators []common.Address
+	if err := client.Client().CallContext(ctx, &validators, "parlia_getValidators", "latest"); err != nil {
+		return nil, err
+	}
+
+	if len(validators) == 0 {
+		return nil, fmt.Errorf("no validators")
+	}
+
+	return validators, nil
+}
+
+// Update replaces the validators, e.g. after a new epoch.
+func (s *ValidatorSet) Update(validators []common.Address) {
+	sorted := append([]common.Address{}, validators...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Bytes(), sorted[j].Bytes()) < 0
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.validators = sorted
+}
+
+// Validators returns the validators, ascending.
+func (s *ValidatorSet) Validators() []common.Address {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]common.Address{}, s.validators...)
+}
+
+// InTurn returns the validator in turn to seal the block of the number, the same as parlia.
+func (s *ValidatorSet) InTurn(number uint64) common.Address {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.validators) == 0 {
+		return common.Address{}
+	}
+
+	return s.validators[number%uint64(len(s.validators))]
+}
+
+// Matches reports whether the header is sealed by the validator in turn of the set, the genesis
+// and a header sealed out of turn, with difficulty 1, tell nothing.
+func (s *ValidatorSet) Matches(header *types.Header) bool {
+	if header.Number.Sign() == 0 || header.Difficulty == nil || header.Difficulty.Uint64() != diffInTurn {
+		return true
+	}
+
+	return s.InTurn(header.Number.Uint64()) == header.Coinbase
+}
+
+// Endpoint returns the client of the mev endpoint of the validator, false if it is not under test.
+func (s *ValidatorSet) Endpoint(validator common.Address) (*ethclient.Client, bool) {
+	client, ok := s.endpoints[validator]
+	return client, ok
+}
+
+// ValidatorConfig is the config file of the validators under test, e.g.
+//
+//	{"endpoints": {"0xe0239549edd90eb0e4abf5cbc9edad1a4af20d3e": "http://127.0.0.1:8080"}}
+type ValidatorConfig struct {
+	// Validators are all the validators of the chain, read by parlia_getValidators if empty
+	Validators []common.Address `json:"validators"`
+	// Endpoints are the mev endpoints of the validators under test
+	Endpoints map[common.Address]string `json:"endpoints"`
+}
+
+// LoadValidatorConfig reads the validator config file.
+func LoadValidatorConfig(path string) (*ValidatorConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := new(ValidatorConfig)
+	if err = json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse %v, %v", path, err)
+	}
+
+	if len(config.Endpoints) == 0 {
+		return nil, fmt.Errorf("no endpoints in %v", path)
+	}
+
+	return config, nil
+}
+
+// Dial connects to the endpoints of the validators, and reads the validators from the full
+// node if they are not configured.
+func (c *ValidatorConfig) Dial(ctx context.Context, fullNode *ethclient.Client, options ...rpc.ClientOption) (
+	*ValidatorSet, error) {
+	validators := c.Validators
+	if len(validators) == 0 {
+		var err error
+		if validators, err = FetchValidators(ctx, fullNode); err != nil {
+			return nil, fmt.Errorf("parlia_getValidators, %v", err)
+		}
+	}
+
+	endpoints := make(map[common.Address]*ethclient.Client, len(c.Endpoints))
+	for validator, url := range c.Endpoints {
+		client, err := ethclient.DialOptions(ctx, url, options...)
+		if err != nil {
+			return nil, fmt.Errorf("dial %v of validator %v, %v", url, validator, err)
+		}
+
+		endpoints[validator] = client
+	}
+
+	return NewValidatorSet(validators, endpoints), nil
+}
+
+// validatorAt returns the validator in turn to seal the block of the number, the bid of the
+// block is sent to it and its PayBidTx pays it.
+func (arg *BidCaseArg) validatorAt(number uint64) common.Address {
+	if arg.ValidatorSet == nil {
+		return arg.Validators[0]
+	}
+
+	return arg.ValidatorSet.InTurn(number)
+}
+
+// mevClient returns the client of the mev endpoint the bid of the block number is sent to.
+func (arg *BidCaseArg) mevClient(number uint64) *ethclient.Client {
+	if arg.ValidatorSet != nil {
+		if client, ok := arg.ValidatorSet.Endpoint(arg.validatorAt(number)); ok {
+			return client
+		}
+	}
+
+	return arg.Client
+}
+
+// waitForValidatorInTurn waits for the next block to be sealed by a validator under test,
+// the validators are read again if the head is not sealed by the one in turn of the set.
+func waitForValidatorInTurn(arg *BidCaseArg) error {
+	set := arg.ValidatorSet
+	for {
+		head, err := arg.FullNode.HeaderByNumber(arg.Ctx, nil)
+		if err == nil {
+			if !set.Matches(head) {
+				log.Warnw("head not sealed by the validator in turn, read validators again",
+					"number", head.Number, "coinbase", head.Coinbase)
+				if validators, er := FetchValidators(arg.Ctx, arg.FullNode); er == nil {
+					set.Update(validators)
+				}
+			}
+
+			if _, ok := set.Endpoint(set.InTurn(head.Number.Uint64() + 1)); ok {
+				return nil
+			}
+		}
+
+		println("wait for in turn")
+		if err = sleepCtx(arg.Ctx, DefaultPollInterval); err != nil {
+			return fmt.Errorf("wait for in turn, %w", err)
+		}
+	}
+}
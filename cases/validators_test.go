@@ -0,0 +1,123 @@
+package cases
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/bsc-mev-cases/mevtest"
+)
+
+func TestValidatorSet(t *testing.T) {
+	v1, v2, v3 := common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03")
+	set := NewValidatorSet([]common.Address{v3, v1, v2}, map[common.Address]*ethclient.Client{v2: nil})
+
+	assert.Equal(t, []common.Address{v1, v2, v3}, set.Validators())
+	assert.Equal(t, v1, set.InTurn(3))
+	assert.Equal(t, v2, set.InTurn(4))
+
+	_, ok := set.Endpoint(v2)
+	assert.True(t, ok)
+	_, ok = set.Endpoint(v1)
+	assert.False(t, ok)
+
+	header := &types.Header{Number: big.NewInt(4), Coinbase: v2, Difficulty: big.NewInt(2)}
+	assert.True(t, set.Matches(header))
+	header.Coinbase = v3
+	assert.False(t, set.Matches(header))
+	header.Difficulty = big.NewInt(1)
+	assert.True(t, set.Matches(header))
+
+	set.Update([]common.Address{v1, v3})
+	assert.Equal(t, v3, set.InTurn(3))
+}
+
+func TestLoadValidatorConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "validators.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"endpoints": {"0xe0239549edd90eb0e4abf5cbc9edad1a4af20d3e": "http://127.0.0.1:8080"}}`), 0o644))
+
+	config, err := LoadValidatorConfig(path)
+	assert.Nil(t, err)
+	assert.Empty(t, config.Validators)
+	assert.Equal(t, "http://127.0.0.1:8080", config.Endpoints[common.HexToAddress("0xe0239549edd90eb0e4abf5cbc9edad1a4af20d3e")])
+
+	assert.Nil(t, os.WriteFile(path, []byte(`{"validators": ["0xe0239549edd90eb0e4abf5cbc9edad1a4af20d3e"]}`), 0o644))
+	_, err = LoadValidatorConfig(path)
+	assert.ErrorContains(t, err, "no endpoints")
+}
+
+func TestMultiValidators(t *testing.T) {
+	if testing.Short() {
+		t.Skip("valid cases wait for receipts")
+	}
+
+	rootKey, root := newTestKey()
+	bobKey, _ := newTestKey()
+	builderKey, builder := newTestKey()
+
+	chain := mevtest.NewChain(mevtest.ChainConfig{
+		Alloc: map[common.Address]*big.Int{root: testBalance, builder: testBalance},
+	})
+
+	servers := make([]*mevtest.Server, 0, 3)
+	for _, v := range []string{"0x03", "0x01", "0x02"} {
+		server := mevtest.NewServer(chain, mevtest.Config{
+			Validator: common.HexToAddress(v),
+			Builders:  []common.Address{builder},
+		})
+		t.Cleanup(server.Close)
+		servers = append(servers, server)
+	}
+
+	chain.Start(200 * time.Millisecond)
+	t.Cleanup(chain.Stop)
+
+	ctx := context.Background()
+	fullNode, err := ethclient.Dial(servers[0].URL)
+	assert.Nil(t, err)
+
+	// the validator 0x01 is not under test
+	config := &ValidatorConfig{Endpoints: map[common.Address]string{
+		servers[0].Validator(): servers[0].URL,
+		servers[2].Validator(): servers[2].URL,
+	}}
+	set, err := config.Dial(ctx, fullNode)
+	assert.Nil(t, err)
+	assert.Equal(t, chain.Validators(), set.Validators())
+
+	arg := &BidCaseArg{
+		Ctx:          ctx,
+		Client:       fullNode,
+		FullNode:     fullNode,
+		Root:         rootKey,
+		Bob:          bobKey,
+		Builder:      NewAccount(ctx, fullNode, builderKey, nil),
+		ValidatorSet: set,
+		Nonces:       NewNonces(fullNode),
+		CaseTimeout:  30 * time.Second,
+	}
+
+	coinbases := make(map[common.Address]bool)
+	for i := 0; i < 4; i++ {
+		for _, res := range RunCase(arg, "ValidBid_PayBidTx_200") {
+			assert.Equal(t, StatusPassed, res.Status, "%s: %s", res.Name, res.Error)
+
+			block, err := fullNode.BlockByNumber(ctx, new(big.Int).SetUint64(res.BlockNumber))
+			assert.Nil(t, err)
+			coinbases[block.Coinbase()] = true
+		}
+	}
+
+	assert.False(t, coinbases[servers[1].Validator()])
+	for _, server := range servers {
+		assert.Empty(t, server.Issues())
+	}
+}
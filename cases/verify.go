@@ -0,0 +1,121 @@
+package cases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"go.uber.org/multierr"
+)
+
+// VerifyBid checks the bid the same way a validator does before simulating it,
+// so a rejection can be told from a bug of the tooling.
+func VerifyBid(bidArgs *types.BidArgs, builder common.Address, chainID *big.Int) error {
+	return multierr.Combine(
+		VerifyBidSignature(bidArgs, builder),
+		VerifyBidTxs(bidArgs, chainID),
+	)
+}
+
+// VerifyBidSignature checks the bid is signed by the builder over its fields, and the RawBid
+// is encoded the same after sent over json-rpc.
+func VerifyBidSignature(bidArgs *types.BidArgs, builder common.Address) error {
+	if bidArgs.RawBid == nil {
+		return errors.New("nil raw bid")
+	}
+
+	hash, err := rawBidHash(bidArgs.RawBid)
+	if err != nil {
+		return err
+	}
+
+	// RawBid.Hash is cached, and signed by the builder
+	if hash != bidArgs.RawBid.Hash() {
+		return fmt.Errorf("signing hash %v differs from raw bid hash %v", hash, bidArgs.RawBid.Hash())
+	}
+
+	var errs error
+
+	pk, err := crypto.SigToPub(hash.Bytes(), bidArgs.Signature)
+	if err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("invalid signature, %v", err))
+	} else if signer := crypto.PubkeyToAddress(*pk); signer != builder {
+		errs = multierr.Append(errs, fmt.Errorf("bid signed by %v, expected builder %v", signer, builder))
+	}
+
+	wire, err := json.Marshal(bidArgs)
+	if err != nil {
+		return multierr.Append(errs, fmt.Errorf("failed to marshal bid, %v", err))
+	}
+
+	received := new(types.BidArgs)
+	if err = json.Unmarshal(wire, received); err != nil {
+		return multierr.Append(errs, fmt.Errorf("failed to unmarshal bid, %v", err))
+	}
+
+	if received.RawBid == nil || received.RawBid.Hash() != hash {
+		errs = multierr.Append(errs, errors.New("raw bid changes after json round trip"))
+	}
+
+	return errs
+}
+
+// rawBidHash hashes the fields of the raw bid in the order the validator encodes them,
+// independent of the encoding of RawBid itself.
+func rawBidHash(raw *types.RawBid) (common.Hash, error) {
+	data, err := rlp.EncodeToBytes([]interface{}{
+		raw.BlockNumber,
+		raw.ParentHash,
+		raw.Txs,
+		raw.GasUsed,
+		raw.GasFee,
+		raw.BuilderFee,
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode raw bid, %v", err)
+	}
+
+	return crypto.Keccak256Hash(data), nil
+}
+
+// VerifyBidTxs checks every tx and the PayBidTx of the bid can be decoded with a valid sender.
+func VerifyBidTxs(bidArgs *types.BidArgs, chainID *big.Int) error {
+	if bidArgs.RawBid == nil {
+		return errors.New("nil raw bid")
+	}
+
+	signer := types.LatestSignerForChainID(chainID)
+
+	var errs error
+	for i, txBytes := range bidArgs.RawBid.Txs {
+		if err := verifyTx(signer, txBytes); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("tx at index %v, %v", i, err))
+		}
+	}
+
+	if len(bidArgs.PayBidTx) != 0 {
+		if err := verifyTx(signer, bidArgs.PayBidTx); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("payBidTx, %v", err))
+		}
+	}
+
+	return errs
+}
+
+func verifyTx(signer types.Signer, txBytes []byte) error {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(txBytes); err != nil {
+		return fmt.Errorf("failed to decode, %v", err)
+	}
+
+	if _, err := types.Sender(signer, tx); err != nil {
+		return fmt.Errorf("invalid sender, %v", err)
+	}
+
+	return nil
+}
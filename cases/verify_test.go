@@ -0,0 +1,63 @@
+package cases
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyBid(t *testing.T) {
+	arg, server := newTestArg(t, 0)
+	chainID := server.Chain().ChainID()
+
+	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 2)
+	gasFee := big.NewInt(BNBGasUsed * 2 * 1e9)
+	bidArgs, err := generateValidBid(arg, txs, BNBGasUsed*2, gasFee, true, BuilderFee)
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyBid(bidArgs, arg.Builder.Address, chainID))
+
+	err = VerifyBid(bidArgs, common.HexToAddress("0x01"), chainID)
+	assert.ErrorContains(t, err, "expected builder")
+
+	tampered := &types.RawBid{
+		BlockNumber: bidArgs.RawBid.BlockNumber,
+		ParentHash:  bidArgs.RawBid.ParentHash,
+		Txs:         bidArgs.RawBid.Txs,
+		GasUsed:     bidArgs.RawBid.GasUsed + 1,
+		GasFee:      bidArgs.RawBid.GasFee,
+		BuilderFee:  bidArgs.RawBid.BuilderFee,
+	}
+	err = VerifyBid(&types.BidArgs{RawBid: tampered, Signature: bidArgs.Signature}, arg.Builder.Address, chainID)
+	assert.ErrorContains(t, err, "expected builder")
+
+	// the hash is cached, changing the raw bid after hashed is a bug of the tooling
+	tampered.Hash()
+	tampered.GasUsed++
+	err = VerifyBid(&types.BidArgs{RawBid: tampered, Signature: bidArgs.Signature}, arg.Builder.Address, chainID)
+	assert.ErrorContains(t, err, "differs from raw bid hash")
+
+	err = VerifyBid(&types.BidArgs{}, arg.Builder.Address, chainID)
+	assert.ErrorContains(t, err, "nil raw bid")
+
+	// the fields are hashed as RawBid, nil fees included
+	for _, raw := range []*types.RawBid{bidArgs.RawBid, {BlockNumber: 1, GasUsed: uint64(BNBGasUsed)}} {
+		hash, err := rawBidHash(raw)
+		assert.Nil(t, err)
+		assert.Equal(t, raw.Hash(), hash)
+	}
+
+	unsigned := generateBNBTxsNoSign(arg, TransferAmountPerTx, 2)
+	bidArgs, err = generateValidBid(arg, append(txs[:1], unsigned...), BNBGasUsed*3, gasFee, false, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyBidSignature(bidArgs, arg.Builder.Address))
+	err = VerifyBidTxs(bidArgs, chainID)
+	assert.ErrorContains(t, err, "tx at index 1")
+	assert.ErrorContains(t, err, "tx at index 2")
+	assert.NotContains(t, err.Error(), "tx at index 0")
+
+	bidArgs.PayBidTx = []byte{0x01}
+	assert.ErrorContains(t, VerifyBidTxs(bidArgs, chainID), "payBidTx")
+}
@@ -0,0 +1,165 @@
+package cases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// DefaultPollInterval is the interval polling the head of the full node.
+const DefaultPollInterval = 200 * time.Millisecond
+
+// Waiter waits for blocks and receipts by polling the head of a full node.
+type Waiter struct {
+	client *ethclient.Client
+
+	// Interval is the interval polling the head
+	Interval time.Duration
+	// Confirmations is the number of blocks waited after the block, before reading it
+	Confirmations uint64
+
+	// metrics counts the bids waited by WaitForBid for the case, not counted if nil
+	metrics  *Metrics
+	caseName string
+}
+
+// NewWaiter creates a waiter polling the full node every DefaultPollInterval.
+func NewWaiter(client *ethclient.Client, confirmations uint64) *Waiter {
+	return &Waiter{
+		client:        client,
+		Interval:      DefaultPollInterval,
+		Confirmations: confirmations,
+	}
+}
+
+// WaitForBlock waits until the block of the number is sealed and confirmed, and returns it.
+func (w *Waiter) WaitForBlock(ctx context.Context, number uint64) (*types.Block, error) {
+	for {
+		head, err := w.client.BlockNumber(ctx)
+		if err == nil && head >= number+w.Confirmations {
+			return w.client.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+		}
+
+		if er := sleepCtx(ctx, w.Interval); er != nil {
+			return nil, fmt.Errorf("wait for block %v, %w", number, er)
+		}
+	}
+}
+
+// WaitForTxs waits for the block of the number, checks the txs are included in the block
+// in order, and returns their receipts.
+func (w *Waiter) WaitForTxs(ctx context.Context, number uint64, txs types.Transactions) ([]*types.Receipt, error) {
+	block, err := w.WaitForBlock(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = containsInOrder(block, txs); err != nil {
+		return nil, err
+	}
+
+	return w.receipts(ctx, txs)
+}
+
+// WaitForBid waits for the block of the bid, checks the block is sealed with the bid by
+// AssertBidInBlock, and returns the receipts of the txs of the bid, followed by its PayBidTx.
+func (w *Waiter) WaitForBid(ctx context.Context, bidArgs *types.BidArgs) ([]*types.Receipt, error) {
+	start := time.Now()
+	receipts, err := w.waitForBid(ctx, bidArgs)
+	w.metrics.observeWait(w.caseName, time.Since(start), bidArgs, receipts, err)
+
+	return receipts, err
+}
+
+func (w *Waiter) waitForBid(ctx context.Context, bidArgs *types.BidArgs) ([]*types.Receipt, error) {
+	block, err := w.WaitForBlock(ctx, bidArgs.RawBid.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = AssertBidInBlock(block, bidArgs); err != nil {
+		return nil, err
+	}
+
+	txs, err := decodeBidTxs(bidArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.receipts(ctx, txs)
+}
+
+// WaitForReceipts waits for the receipts of the txs, which are included in any block,
+// e.g. txs of a bundle.
+func (w *Waiter) WaitForReceipts(ctx context.Context, txs types.Transactions) ([]*types.Receipt, error) {
+	for {
+		receipts, err := w.receipts(ctx, txs)
+		if !errors.Is(err, ethereum.NotFound) {
+			if err != nil || w.Confirmations == 0 {
+				return receipts, err
+			}
+
+			last := uint64(0)
+			for _, receipt := range receipts {
+				if n := receipt.BlockNumber.Uint64(); n > last {
+					last = n
+				}
+			}
+
+			if _, err = w.WaitForBlock(ctx, last); err != nil {
+				return nil, err
+			}
+
+			// receipts may change if reorged before confirmed
+			return w.receipts(ctx, txs)
+		}
+
+		if er := sleepCtx(ctx, w.Interval); er != nil {
+			return nil, fmt.Errorf("wait for receipts, %v, %w", err, er)
+		}
+	}
+}
+
+func (w *Waiter) receipts(ctx context.Context, txs types.Transactions) ([]*types.Receipt, error) {
+	receipts := make([]*types.Receipt, 0, len(txs))
+	for i, tx := range txs {
+		receipt, err := w.client.TransactionReceipt(ctx, tx.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("receipt of tx at index %v, %w", i, err)
+		}
+
+		receipts = append(receipts, receipt)
+	}
+
+	return receipts, nil
+}
+
+// containsInOrder checks the block contains all the txs in the same order.
+func containsInOrder(block *types.Block, txs types.Transactions) error {
+	index := make(map[common.Hash]int, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		index[tx.Hash()] = i
+	}
+
+	last := -1
+	for i, tx := range txs {
+		pos, ok := index[tx.Hash()]
+		if !ok {
+			return fmt.Errorf("tx at index %v not included in block %v", i, block.NumberU64())
+		}
+
+		if pos <= last {
+			return fmt.Errorf("tx at index %v out of order in block %v", i, block.NumberU64())
+		}
+		last = pos
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package cases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForTxs(t *testing.T) {
+	arg, _ := newTestArg(t, 100*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 3)
+	acc, err := simulateBid(arg, txs)
+	assert.Nil(t, err)
+	bidArgs, err := generateValidBid(arg, txs, int64(acc.GasUsed()), acc.GasFee(), false, nil)
+	assert.Nil(t, err)
+
+	for {
+		if _, err = arg.sendBid(bidArgs); err == nil {
+			break
+		}
+		// sealed before sent
+		bidArgs, err = generateValidBid(arg, txs, int64(acc.GasUsed()), acc.GasFee(), false, nil)
+		assert.Nil(t, err)
+	}
+
+	waiter := NewWaiter(arg.FullNode, 2)
+	receipts, err := waiter.WaitForTxs(ctx, bidArgs.RawBid.BlockNumber, txs)
+	assert.Nil(t, err)
+	assert.Nil(t, assertReceiptsSucceed(receipts))
+
+	receipts, err = waiter.WaitForBid(ctx, bidArgs)
+	assert.Nil(t, err)
+	assert.Len(t, receipts, len(txs))
+
+	head, err := arg.FullNode.BlockNumber(ctx)
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, head, bidArgs.RawBid.BlockNumber+2)
+
+	_, err = waiter.WaitForTxs(ctx, bidArgs.RawBid.BlockNumber, types.Transactions{txs[1], txs[0]})
+	assert.ErrorContains(t, err, "tx at index 1 out of order")
+
+	_, err = waiter.WaitForTxs(ctx, bidArgs.RawBid.BlockNumber+1, txs)
+	assert.ErrorContains(t, err, "tx at index 0 not included")
+
+	expired, cancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer cancel()
+	_, err = waiter.WaitForBlock(expired, head+100)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWaitForReceipts(t *testing.T) {
+	arg, _ := newTestArg(t, 100*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	txs := GenerateBNBTxs(arg, TransferAmountPerTx, 2)
+	txBytes := make([]hexutil.Bytes, 0, len(txs))
+	for _, tx := range txs {
+		b, err := tx.MarshalBinary()
+		assert.Nil(t, err)
+		txBytes = append(txBytes, b)
+	}
+	assert.Nil(t, arg.FullNode.SendBundle(ctx, &types.SendBundleArgs{Txs: txBytes}))
+
+	receipts, err := NewWaiter(arg.FullNode, 1).WaitForReceipts(ctx, txs)
+	assert.Nil(t, err)
+	assert.Len(t, receipts, 2)
+	assert.Nil(t, assertReceiptsSucceed(receipts))
+}
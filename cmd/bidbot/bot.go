@@ -3,6 +3,12 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -15,45 +21,118 @@ import (
 )
 
 var (
-	chainURL = flag.String("chain", "http://127.0.0.1:8545", "chain rpc url")
+	chainURL    = flag.String("chain", "http://127.0.0.1:8545", "chain rpc url")
+	fullNodeURL = flag.String("fullnode", "http://127.0.0.1:8545", "full node rpc url for chain states, with the debug api to simulate bids")
 
 	// setting: root bnb&abc boss
-	rootPrivateKey = flag.String("rootpk",
-		"59ba8068eb256d520179e903f43dacf6d8d57d72bd306e1bd603fdb8c8da10e8",
-		"private key of root account")
-	bobPrivateKey = flag.String("bobpk",
-		"23ca29fc7e75f2a303428ee2d5526476279cabbf15c9749d1fdb080f6287e06f",
-		"private key of bob account")
-	builderPrivateKey = flag.String("builderpk",
-		"7b94e64fc431b0daa238d6ed8629f3747782b8bc10fb8a41619c5fb2ba55f4e3",
-		"private key of builder account")
+	rootKey    = flag.String("root-key", "", "key of root account, "+cases.SignerSpecUsage)
+	bobKey     = flag.String("bob-key", "", "key of bob account, "+cases.SignerSpecUsage)
+	builderKey = flag.String("builder-key", "", "key of builder account, "+cases.SignerSpecUsage)
+
+	competingBuilderKeys = flag.String("competing-builder-keys", "",
+		"comma separated keys of the builders competing in the competition cases")
+
+	passwordFile = flag.String("keystore-password-file", "", "file of the password of the keystore keys")
+	passwordEnv  = flag.String("keystore-password-env", "", "env of the password of the keystore keys, if -keystore-password-file is empty")
 
 	abcAddress = flag.String("abc", "0xC806e70a62eaBC56E3Ee0c2669c2FF14452A9B3d", "abc contract address")
 
-	validator = flag.String("validator", "0xe0239549edd90eb0e4abf5cbc9edad1a4af20d3e", "validator address")
+	validator  = flag.String("validator", "0xe0239549edd90eb0e4abf5cbc9edad1a4af20d3e", "validator address")
+	validators = flag.String("validators", "",
+		"path of the validator config, bids are sent to the mev endpoint of the validator in turn, overrides -validator")
 
 	casetype = flag.String("casetype", "valid", "case type")
 	sentry   = flag.String("sentry", "http://127.0.0.1:8080", "sentry url")
 	casename = flag.String("casename", "", "case name")
+	tags     = flag.String("tags", "", "comma separated case tags to run, e.g. valid,pay-bid, overrides casetype")
+	list     = flag.Bool("list", false, "list the registered cases matching -tags and exit")
+	scenario = flag.String("scenario", "",
+		"path of a YAML or JSON scenario file, its scenarios are registered as cases tagged scenario and run unless -casetype given")
+
+	caseTimeout = flag.Duration("case-timeout", 5*time.Minute, "deadline of each case, 0 for no deadline")
+	maxRetries  = flag.Int("max-retries", 100, "max bids a case resends, 0 for unlimited")
+
+	fuzzIterations = flag.Int("fuzz-iterations", 1000, "number of fuzzed bids sent by casetype fuzz")
+	fuzzSeed       = flag.Int64("fuzz-seed", 0, "seed of casetype fuzz, random if 0")
+	fuzzDir        = flag.String("fuzz-dir", "fuzz-failures", "directory the fuzzed bids of unexpected responses are saved to")
+
+	loadRate           = flag.Float64("load-rate", 10, "target bids per second of casetype load")
+	loadBuilders       = flag.Int("load-builders", 1, "builder identities of casetype load, -builder-key and the first of -competing-builder-keys")
+	loadSizes          = flag.String("load-sizes", "8,32,512", "comma separated txs:weight of the bids of casetype load, e.g. 8:2,512:1")
+	loadDuration       = flag.Duration("load-duration", time.Minute, "how long casetype load sends bids")
+	loadConcurrency    = flag.Int("load-concurrency", 16, "max bids in flight of casetype load, bids due beyond are dropped")
+	loadReportInterval = flag.Duration("load-report-interval", 10*time.Second, "interval of the reports of casetype load, 0 to report only at the end")
+
+	stableDuration = flag.Duration("stable-duration", cases.DefaultStableDuration, "how long casetype stable runs")
+	stableInterval = flag.Duration("stable-interval", cases.DefaultStableInterval, "interval between the cases of casetype stable")
+	stableWeights  = flag.String("stable-weights", "", "comma separated name:weight of the stable cases, 1 if absent, 0 to skip a case")
+	stableSeed     = flag.Int64("stable-seed", 0, "seed picking the stable cases, random if 0")
+	stableState    = flag.String("stable-state", "stable-state.json", "path the progress of casetype stable is saved to, not saved if empty")
+	resume         = flag.Bool("resume", false, "resume casetype stable from -stable-state, with its seed and weights")
+
+	confirmations = flag.Uint64("confirmations", 0, "blocks waited after the block of a bid before checking its txs")
+
+	validatorLog = flag.String("validator-log", "", "path of the validator log to check the cases against, - to read it from stdin")
+	logWindow    = flag.Duration("log-window", cases.DefaultLogWindow, "time waited for a message in the validator log after a bid is sent")
+
+	reportJSON  = flag.String("report-json", "", "path to write case results as JSON lines")
+	reportJUnit = flag.String("report-junit", "", "path to write case results as JUnit XML")
+
+	metricsAddr    = flag.String("metrics-addr", "", "address serving the Prometheus metrics of the run at /metrics, e.g. :9090, not served if empty")
+	metricsSummary = flag.String("metrics-summary", "", "path to write the summary of the metrics of the run as JSON")
+
+	poolMnemonic = flag.String("pool-mnemonic", "",
+		"file:PATH or env:NAME of the mnemonic of the accounts funded by `sol -option fund`, the txs of the cases are sent by them instead of root")
+	poolSeed    = flag.String("pool-seed", "", "file:PATH or env:NAME of the hex seed of the pool accounts, if -pool-mnemonic is empty")
+	poolSize    = flag.Int("pool-size", 10, "number of the pool accounts")
+	poolSenders = flag.Int("pool-senders", 1, "number of the pool accounts sending the txs of each case")
+
+	record = flag.String("record", "", "path of the file every bid sent is appended to with its response, for replay")
 )
 
 func main() {
 	flag.Parse()
+	os.Exit(run())
+}
+
+// run runs the command and returns its exit code, so the deferred closes run before the exit.
+func run() int {
+	if flag.Arg(0) == "verify-bid" {
+		return verifyBid(flag.Args()[1:])
+	}
+
+	whatcase := *casetype
+	if *scenario != "" {
+		loadScenarios(*scenario)
+		if !isFlagSet("casetype") {
+			whatcase = "scenario"
+		}
+	}
+
+	if *list {
+		err := cases.PrintCases(os.Stdout, cases.Cases(cases.ParseTags(*tags)...))
+		if err != nil {
+			log.Errorw("cases.PrintCases", "err", err)
+		}
+		return 0
+	}
 
 	ctx := context.Background()
 
-	rootPk := *rootPrivateKey
-	bobPk := *bobPrivateKey
-	builderPk := *builderPrivateKey
+	builder := loadSigner("builder-key", *builderKey)
 	url := *chainURL
-	whatcase := *casetype
 
 	client, err := ethclient.DialOptions(ctx, url, rpc.WithHTTPClient(utils.Client))
 	if err != nil {
 		log.Errorw("ethclient.DialOptions", "err", err)
 	}
 
-	abcSol, err := abc.NewAbc(common.HexToAddress(*abcAddress), client)
+	fullNode, err := ethclient.DialOptions(ctx, *fullNodeURL, rpc.WithHTTPClient(utils.Client))
+	if err != nil {
+		log.Errorw("ethclient.DialOptions", "err", err)
+	}
+
+	abcSol, err := abc.NewAbc(common.HexToAddress(*abcAddress), fullNode)
 	if err != nil {
 		log.Errorw("abc.NewAbc", "err", err)
 	}
@@ -61,25 +140,259 @@ func main() {
 	arg := &cases.BidCaseArg{
 		Ctx:        ctx,
 		Client:     client,
-		RootPk:     rootPk,
-		BobPk:      bobPk,
+		FullNode:   fullNode,
+		Root:       loadSigner("root-key", *rootKey),
+		Bob:        loadSigner("bob-key", *bobKey),
 		Abc:        abcSol,
-		Builder:    cases.NewAccount(builderPk, abcSol),
+		Builder:    cases.NewAccount(ctx, fullNode, builder, abcSol),
 		Validators: []common.Address{common.HexToAddress(*validator)},
+		Nonces:     cases.NewNonces(fullNode),
+
+		CaseTimeout: *caseTimeout,
+		MaxRetries:  *maxRetries,
+
+		Confirmations: *confirmations,
+
+		LogWindow: *logWindow,
+
+		Metrics: cases.NewMetrics(),
+	}
+
+	if *poolMnemonic != "" || *poolSeed != "" {
+		seed, err := cases.LoadAccountSeed(*poolMnemonic, *poolSeed)
+		if err != nil {
+			log.Panicw("cases.LoadAccountSeed", "err", err)
+		}
+
+		accounts, err := cases.DeriveAccounts(seed, *poolSize, abcSol)
+		if err != nil {
+			log.Panicw("cases.DeriveAccounts", "err", err)
+		}
+
+		arg.Pool = cases.NewAccountPool(accounts)
+		arg.SendersPerCase = *poolSenders
+	}
+
+	if *metricsAddr != "" {
+		serveMetrics(*metricsAddr, arg.Metrics)
+	}
+
+	if *competingBuilderKeys != "" {
+		for _, spec := range strings.Split(*competingBuilderKeys, ",") {
+			arg.CompetingBuilders = append(arg.CompetingBuilders, loadSigner("competing-builder-keys", spec))
+		}
+	}
+
+	if *validators != "" {
+		config, err := cases.LoadValidatorConfig(*validators)
+		if err != nil {
+			log.Panicw("cases.LoadValidatorConfig", "err", err)
+		}
+
+		arg.ValidatorSet, err = config.Dial(ctx, fullNode, rpc.WithHTTPClient(utils.Client))
+		if err != nil {
+			log.Panicw("ValidatorConfig.Dial", "err", err)
+		}
+	}
+
+	if *validatorLog != "" {
+		arg.Logs = watchValidatorLog(*validatorLog)
+		defer arg.Logs.Close()
+	}
+
+	if *record != "" {
+		arg.Recorder, err = cases.OpenBidRecorder(*record)
+		if err != nil {
+			log.Panicw("cases.OpenBidRecorder", "err", err)
+		}
+		defer arg.Recorder.Close()
+	}
+
+	if flag.Arg(0) == "replay" {
+		return replay(arg, flag.Args()[1:])
+	}
+
+	suite := "bidbot-" + whatcase
+	var results []*cases.CaseResult
+	if *tags != "" {
+		suite = "bidbot-" + *tags
+		results = cases.RunCases(arg, cases.ParseTags(*tags)...)
+	} else {
+		switch whatcase {
+		case "valid":
+			results = cases.RunValidCases(arg)
+		case "invalid":
+			results = cases.RunInvalidCases(arg)
+		case "stable":
+			weights, err := cases.ParseCaseWeights(*stableWeights)
+			if err != nil {
+				log.Panicw("cases.ParseCaseWeights", "err", err)
+			}
+
+			seed := *stableSeed
+			if seed == 0 {
+				seed = time.Now().UnixNano()
+			}
+
+			results, err = cases.RunStableCases(arg, cases.StableConfig{
+				Duration:  *stableDuration,
+				Interval:  *stableInterval,
+				Weights:   weights,
+				Seed:      seed,
+				StateFile: *stableState,
+				Resume:    *resume,
+			})
+			if err != nil {
+				log.Errorw("cases.RunStableCases", "err", err)
+			}
+		case "concurrency":
+			results = cases.RunConcurrency(arg)
+		case "single":
+			results = cases.RunCase(arg, *casename)
+		case "query":
+			results = cases.RunQueryCases(arg)
+		case "competition":
+			results = cases.RunCompetitionCases(arg)
+		case "scenario":
+			results = cases.RunScenarioCases(arg)
+		case "fuzz":
+			seed := *fuzzSeed
+			if seed == 0 {
+				seed = time.Now().UnixNano()
+			}
+
+			results = cases.RunFuzz(arg, cases.FuzzConfig{Iterations: *fuzzIterations, Seed: seed, Dir: *fuzzDir})
+		case "load":
+			sizes, err := cases.ParseBidSizes(*loadSizes)
+			if err != nil {
+				log.Panicw("cases.ParseBidSizes", "err", err)
+			}
+
+			results = cases.RunLoad(arg, cases.LoadConfig{
+				Rate:           *loadRate,
+				Builders:       *loadBuilders,
+				Sizes:          sizes,
+				Duration:       *loadDuration,
+				Concurrency:    *loadConcurrency,
+				ReportInterval: *loadReportInterval,
+			})
+		default:
+			log.Errorw("unknown case type", "casetype", whatcase)
+			return 2
+		}
+	}
+
+	writeReports(suite, results)
+	writeMetricsSummary(arg.Metrics.Summary())
+
+	if cases.AnyFailed(results) {
+		return 1
+	}
+
+	return 0
+}
+
+// loadScenarios registers the scenarios of the file as cases.
+func loadScenarios(path string) {
+	scenarios, err := cases.LoadScenarios(path)
+	if err != nil {
+		log.Panicw("cases.LoadScenarios", "err", err)
+	}
+
+	if err = cases.RegisterScenarios(scenarios); err != nil {
+		log.Panicw("cases.RegisterScenarios", "err", err)
+	}
+}
+
+// loadSigner loads the signer of the key of the flag, the key password is read from
+// -keystore-password-file or -keystore-password-env.
+func loadSigner(name, spec string) cases.Signer {
+	if spec == "" {
+		log.Panicw("key not set", "flag", name)
 	}
 
-	switch whatcase {
-	case "valid":
-		cases.RunValidCases(arg)
-	case "invalid":
-		cases.RunInvalidCases(arg)
-	case "stable":
-		cases.RunStableCases(arg)
-	case "concurrency":
-		cases.RunConcurrency(arg)
-	case "single":
-		cases.RunCase(arg, *casename)
-	case "query":
-		cases.RunQueryCases(arg)
+	signer, err := cases.LoadSigner(context.Background(), spec, utils.KeyPassword{File: *passwordFile, Env: *passwordEnv})
+	if err != nil {
+		log.Panicw("cases.LoadSigner", "flag", name, "err", err)
 	}
+
+	return signer
+}
+
+// isFlagSet reports whether the flag is given on the command line.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
+
+// watchValidatorLog tails the validator log file, or reads it from stdin if the path is -.
+func watchValidatorLog(path string) *cases.LogWatcher {
+	if path == "-" {
+		return cases.NewLogWatcher(os.Stdin)
+	}
+
+	logs, err := cases.TailLogFile(path, cases.DefaultPollInterval)
+	if err != nil {
+		log.Panicw("cases.TailLogFile", "err", err)
+	}
+
+	return logs
+}
+
+func writeReports(suite string, results []*cases.CaseResult) {
+	if *reportJSON != "" {
+		err := writeReport(*reportJSON, func(w io.Writer) error {
+			return cases.WriteJSONLines(w, results)
+		})
+		if err != nil {
+			log.Errorw("failed to write json report", "err", err)
+		}
+	}
+
+	if *reportJUnit != "" {
+		err := writeReport(*reportJUnit, func(w io.Writer) error {
+			return cases.WriteJUnit(w, suite, results)
+		})
+		if err != nil {
+			log.Errorw("failed to write junit report", "err", err)
+		}
+	}
+}
+
+// serveMetrics serves the metrics at /metrics of the address in the background.
+func serveMetrics(addr string, metrics *cases.Metrics) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Errorw("failed to serve metrics", "addr", addr, "err", err)
+		}
+	}()
+}
+
+func writeMetricsSummary(summary *cases.MetricsSummary) {
+	fmt.Print(summary)
+
+	if *metricsSummary != "" {
+		if err := writeReport(*metricsSummary, summary.WriteJSON); err != nil {
+			log.Errorw("failed to write metrics summary", "err", err)
+		}
+	}
+}
+
+func writeReport(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return write(f)
 }
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bnb-chain/bsc-mev-cases/cases"
+)
+
+// replay runs `bidbot [flags] replay [-speed x] bids.jsonl`, it sends the bids recorded by -record
+// to -chain again, re-targeted to the head of -fullnode and re-signed by -builder-key. It fails if
+// any bid is answered differently from the record.
+func replay(arg *cases.BidCaseArg, args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	speed := fs.Float64("speed", 1, "speed of the replay relative to the recorded intervals, 0 to send the bids back to back")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: bidbot [flags] replay [-speed x] bids.jsonl")
+		return 2
+	}
+
+	records, err := cases.ReadBidRecords(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to read records:", err)
+		return 2
+	}
+
+	results, err := cases.Replay(arg, records, cases.ReplayConfig{Speed: *speed})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "replay failed:", err)
+		return 2
+	}
+
+	differed := 0
+	for i, res := range results {
+		if res.Matches() {
+			continue
+		}
+
+		differed++
+		fmt.Printf("bid %v of %v: recorded code %v %q, replayed code %v %q\n", i, res.Record.Case,
+			res.Record.ErrorCode, res.Record.Error, res.ErrorCode, res.Error)
+	}
+
+	fmt.Printf("%v bids replayed, %v answered differently\n", len(results), differed)
+	if differed > 0 {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/multierr"
+
+	"github.com/bnb-chain/bsc-mev-cases/cases"
+	"github.com/bnb-chain/bsc-mev-cases/utils"
+)
+
+// verifyBid runs `bidbot verify-bid [-builder address] [-chainid id] bid.json`, it checks
+// the bid in the file, i.e. the params of mev_sendBid, without sending it.
+func verifyBid(args []string) int {
+	fs := flag.NewFlagSet("verify-bid", flag.ExitOnError)
+	builder := fs.String("builder", "", "builder address, derived from -builder-key if empty")
+	chainID := fs.Int64("chainid", 0, "chain id, queried from -fullnode if 0")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: bidbot [flags] verify-bid [-builder address] [-chainid id] bid.json")
+		return 2
+	}
+
+	bidArgs, err := cases.LoadBid(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to load bid:", err)
+		return 2
+	}
+
+	builderAddress := common.HexToAddress(*builder)
+	if *builder == "" {
+		signer, err := cases.LoadSigner(context.Background(), *builderKey, utils.KeyPassword{File: *passwordFile, Env: *passwordEnv})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to load builder key:", err)
+			return 2
+		}
+		builderAddress = signer.Address()
+	}
+
+	id := big.NewInt(*chainID)
+	if *chainID == 0 {
+		ctx := context.Background()
+		fullNode, err := ethclient.DialOptions(ctx, *fullNodeURL, rpc.WithHTTPClient(utils.Client))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to dial full node:", err)
+			return 2
+		}
+
+		if id, err = fullNode.ChainID(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to query chain id:", err)
+			return 2
+		}
+	}
+
+	if err = cases.VerifyBid(bidArgs, builderAddress, id); err != nil {
+		fmt.Println("bid verification failed:")
+		for _, e := range multierr.Errors(err) {
+			fmt.Println("  -", e)
+		}
+		return 1
+	}
+
+	fmt.Printf("bid %v verified, builder %v\n", bidArgs.RawBid.Hash(), builderAddress)
+	return 0
+}
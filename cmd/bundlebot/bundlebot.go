@@ -19,12 +19,14 @@ var (
 	chainURL = flag.String("chain", "http://127.0.0.1:8546", "chain rpc url")
 
 	// setting: root bnb&abc boss
-	rootPrivateKey = flag.String("rootpk",
-		"59ba8068eb256d520179e903f43dacf6d8d57d72bd306e1bd603fdb8c8da10e8",
-		"private key of root account")
-	bobPrivateKey = flag.String("bobpk",
-		"23ca29fc7e75f2a303428ee2d5526476279cabbf15c9749d1fdb080f6287e06f",
-		"private key of bob account")
+	rootKey = flag.String("root-key", "", "key of root account, "+cases.SignerSpecUsage)
+	bobKey  = flag.String("bob-key", "", "key of bob account, "+cases.SignerSpecUsage)
+
+	passwordFile = flag.String("keystore-password-file", "", "file of the password of the keystore keys")
+	passwordEnv  = flag.String("keystore-password-env", "", "env of the password of the keystore keys, if -keystore-password-file is empty")
+
+	timeout       = flag.Duration("timeout", time.Minute, "max time waiting for the bundle on chain")
+	confirmations = flag.Uint64("confirmations", 0, "blocks waited after the bundle included")
 )
 
 func main() {
@@ -32,8 +34,6 @@ func main() {
 
 	ctx := context.Background()
 
-	rootPk := *rootPrivateKey
-	bobPk := *bobPrivateKey
 	url := *chainURL
 
 	client, err := ethclient.DialOptions(ctx, url, rpc.WithHTTPClient(utils.Client))
@@ -42,10 +42,11 @@ func main() {
 	}
 
 	arg := &cases.BidCaseArg{
-		Ctx:    ctx,
-		Client: client,
-		RootPk: rootPk,
-		BobPk:  bobPk,
+		Ctx:      ctx,
+		Client:   client,
+		FullNode: client,
+		Root:     loadSigner("root-key", *rootKey),
+		Bob:      loadSigner("bob-key", *bobKey),
 	}
 
 	txs := cases.GenerateBNBTxsWithHighGas(arg, cases.TransferAmountPerTx, 20)
@@ -68,18 +69,34 @@ func main() {
 		log.Panicw("failed to send bundle", "err", err)
 	}
 
-	time.Sleep(10 * time.Second)
+	waitCtx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
 
-	for _, tx := range txs {
-		receipt, err := client.TransactionReceipt(ctx, tx.Hash())
-		if err != nil {
-			log.Panicw("tx not on chain", "err", err)
-		}
+	receipts, err := cases.NewWaiter(client, *confirmations).WaitForReceipts(waitCtx, txs)
+	if err != nil {
+		log.Panicw("tx not on chain", "err", err)
+	}
 
-		if receipt.Status != 1 {
-			log.Panicw("tx failed", "err", err)
+	for _, receipt := range receipts {
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			log.Panicw("tx failed", "tx", receipt.TxHash)
 		}
 	}
 
 	println("bundle success")
 }
+
+// loadSigner loads the signer of the key of the flag, the key password is read from
+// -keystore-password-file or -keystore-password-env.
+func loadSigner(name, spec string) cases.Signer {
+	if spec == "" {
+		log.Panicw("key not set", "flag", name)
+	}
+
+	signer, err := cases.LoadSigner(context.Background(), spec, utils.KeyPassword{File: *passwordFile, Env: *passwordEnv})
+	if err != nil {
+		log.Panicw("cases.LoadSigner", "flag", name, "err", err)
+	}
+
+	return signer
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"flag"
 	"math/big"
 
@@ -13,21 +12,27 @@ import (
 	"github.com/bnb-chain/bsc-mev-cases/abc"
 	"github.com/bnb-chain/bsc-mev-cases/cases"
 	"github.com/bnb-chain/bsc-mev-cases/log"
+	"github.com/bnb-chain/bsc-mev-cases/utils"
 )
 
 var (
 	chainURL = flag.String("chain", "http://127.0.0.1:8545", "chain rpc url")
 
-	rootPrivateKey = flag.String("rootpk",
-		"e61213d0dde8f6d7e73b27ea0304e4db5dc455eb28f6494b353f6cea19f065e7",
-		"private key of root account")
-	builderPrivateKey = flag.String("builderpk",
-		"7b94e64fc431b0daa238d6ed8629f3747782b8bc10fb8a41619c5fb2ba55f4e3",
-		"private key of builder account")
+	rootKey    = flag.String("root-key", "", "key of root account, "+cases.SignerSpecUsage)
+	builderKey = flag.String("builder-key", "", "key of builder account, "+cases.SignerSpecUsage)
+
+	passwordFile = flag.String("keystore-password-file", "", "file of the password of the keystore keys")
+	passwordEnv  = flag.String("keystore-password-env", "", "env of the password of the keystore keys, if -keystore-password-file is empty")
 
 	abcAddress = flag.String("abc", "0xa45F543E97331643cAC26B075F2958d48Bd0E317", "abc contract address")
 
-	option = flag.String("option", "deploy", "deploy or transfer")
+	option = flag.String("option", "deploy", "deploy, balance, transfer or fund")
+
+	mnemonic = flag.String("mnemonic", "", "file:PATH or env:NAME of the mnemonic the accounts funded by option fund are derived from")
+	seed     = flag.String("seed", "", "file:PATH or env:NAME of the hex seed the accounts funded by option fund are derived from, if -mnemonic is empty")
+	accounts = flag.Int("accounts", 10, "number of accounts funded by option fund")
+	fundBNB  = flag.String("fund-bnb", "1", "target BNB balance of the accounts funded by option fund")
+	fundABC  = flag.String("fund-abc", "1", "target ABC balance of the accounts funded by option fund, 0 to skip ABC")
 )
 
 func main() {
@@ -54,6 +59,8 @@ func main() {
 		balance(ctx, client, abcSol)
 	case "transfer":
 		transfer(ctx, client, abcSol)
+	case "fund":
+		fund(ctx, client, abcSol)
 	}
 }
 
@@ -63,8 +70,8 @@ func transfer(ctx context.Context, client *ethclient.Client, abc *abc.Abc) {
 		log.Panic("Client.ChainID", "err", err)
 	}
 
-	root := cases.NewAccount(*rootPrivateKey, abc)
-	builder := cases.NewAccount(*builderPrivateKey, abc)
+	root := cases.NewAccount(ctx, client, loadSigner("root-key", *rootKey), abc)
+	builder := cases.NewAccount(ctx, client, loadSigner("builder-key", *builderKey), abc)
 
 	tx, err := root.TransferABC(root.Nonce, builder.Address, chainID, big.NewInt(1e18))
 	if err != nil {
@@ -88,9 +95,53 @@ func transfer(ctx context.Context, client *ethclient.Client, abc *abc.Abc) {
 	}
 }
 
+// fund tops up the accounts derived from -mnemonic or -seed from root, up to -fund-bnb and -fund-abc.
+func fund(ctx context.Context, client *ethclient.Client, abcSol *abc.Abc) {
+	accountSeed, err := cases.LoadAccountSeed(*mnemonic, *seed)
+	if err != nil {
+		log.Panicw("cases.LoadAccountSeed", "err", err)
+	}
+
+	accs, err := cases.DeriveAccounts(accountSeed, *accounts, abcSol)
+	if err != nil {
+		log.Panicw("cases.DeriveAccounts", "err", err)
+	}
+
+	config := cases.FundConfig{BNB: parseEther(*fundBNB)}
+	if abcTarget := parseEther(*fundABC); abcTarget.Sign() > 0 {
+		config.ABC = abcTarget
+	}
+
+	root := cases.NewAccount(ctx, client, loadSigner("root-key", *rootKey), abcSol)
+	txs, err := cases.FundAccounts(ctx, client, root, accs, config)
+	if err != nil {
+		log.Panicw("cases.FundAccounts", "err", err)
+	}
+
+	for _, acc := range accs {
+		log.Infow("funded account", "address", acc.Address)
+	}
+	log.Infow("funded accounts", "accounts", len(accs), "txs", len(txs))
+}
+
+// parseEther parses a decimal amount of 18 decimals, e.g. 0.5, into wei.
+func parseEther(s string) *big.Int {
+	amount, ok := new(big.Rat).SetString(s)
+	if !ok || amount.Sign() < 0 {
+		log.Panicw("invalid amount", "amount", s)
+	}
+
+	amount.Mul(amount, new(big.Rat).SetInt(big.NewInt(1e18)))
+	if !amount.IsInt() {
+		log.Panicw("amount below 1 wei", "amount", s)
+	}
+
+	return amount.Num()
+}
+
 func balance(ctx context.Context, client *ethclient.Client, abcSol *abc.Abc) {
-	_, rootAddress := cases.PriKeyToAddress(*rootPrivateKey)
-	_, builderAddress := cases.PriKeyToAddress(*builderPrivateKey)
+	rootAddress := loadSigner("root-key", *rootKey).Address()
+	builderAddress := loadSigner("builder-key", *builderKey).Address()
 
 	rootBalance, err := abcSol.BalanceOf(callOpts(), rootAddress)
 	if err != nil {
@@ -108,8 +159,8 @@ func balance(ctx context.Context, client *ethclient.Client, abcSol *abc.Abc) {
 }
 
 func deploy(ctx context.Context, client *ethclient.Client) {
-	rootKey, rootAddress := cases.PriKeyToAddress(*rootPrivateKey)
-	auth := generateAccountAuth(ctx, client, rootKey, rootAddress)
+	root := cases.NewAccount(ctx, client, loadSigner("root-key", *rootKey), nil)
+	auth := generateAccountAuth(ctx, client, root)
 
 	solAddress, _, _, err := abc.DeployAbc(auth, client)
 	if err != nil {
@@ -119,26 +170,14 @@ func deploy(ctx context.Context, client *ethclient.Client) {
 	log.Infow("deployed abc", "address", solAddress)
 }
 
-func generateAccountAuth(ctx context.Context,
-	client *ethclient.Client,
-	key *ecdsa.PrivateKey,
-	address common.Address) *bind.TransactOpts {
-	nonce, err := client.PendingNonceAt(ctx, address)
-	if err != nil {
-		log.Panic(err)
-	}
-
+func generateAccountAuth(ctx context.Context, client *ethclient.Client, account *cases.Account) *bind.TransactOpts {
 	chainID, err := client.ChainID(ctx)
 	if err != nil {
 		log.Panic(err)
 	}
 
-	auth, err := bind.NewKeyedTransactorWithChainID(key, chainID)
-	if err != nil {
-		log.Panic(err)
-	}
-
-	auth.Nonce = big.NewInt(int64(nonce))
+	auth := account.TransactOpts(chainID)
+	auth.Nonce = big.NewInt(int64(account.Nonce))
 	auth.GasLimit = uint64(3000000) // in units
 	auth.GasPrice = big.NewInt(10000000000)
 
@@ -151,3 +190,18 @@ func callOpts() *bind.CallOpts {
 	callOpts.Pending = false
 	return callOpts
 }
+
+// loadSigner loads the signer of the key of the flag, the key password is read from
+// -keystore-password-file or -keystore-password-env.
+func loadSigner(name, spec string) cases.Signer {
+	if spec == "" {
+		log.Panicw("key not set", "flag", name)
+	}
+
+	signer, err := cases.LoadSigner(context.Background(), spec, utils.KeyPassword{File: *passwordFile, Env: *passwordEnv})
+	if err != nil {
+		log.Panicw("cases.LoadSigner", "flag", name, "err", err)
+	}
+
+	return signer
+}
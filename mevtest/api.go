@@ -0,0 +1,254 @@
+package mevtest
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type mevAPI struct {
+	s *Server
+}
+
+func (api *mevAPI) SendBid(args *types.BidArgs) (common.Hash, error) {
+	return api.s.sendBid(args)
+}
+
+func (api *mevAPI) Running() bool {
+	return api.s.Running()
+}
+
+func (api *mevAPI) Params() *types.MevParams {
+	params := api.s.config.Params
+	return &params
+}
+
+func (api *mevAPI) BestBidGasFee(parentHash common.Hash) *big.Int {
+	best := api.s.bestBid(parentHash)
+	if best == nil {
+		return big.NewInt(0)
+	}
+
+	return best.args.RawBid.GasFee
+}
+
+type parliaAPI struct {
+	chain *Chain
+}
+
+func (api *parliaAPI) GetValidators(number *rpc.BlockNumber) []common.Address {
+	return api.chain.Validators()
+}
+
+type ethAPI struct {
+	chain *Chain
+}
+
+func (api *ethAPI) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(api.chain.ChainID())
+}
+
+func (api *ethAPI) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(api.chain.Head().NumberU64())
+}
+
+func (api *ethAPI) GetBlockByNumber(number rpc.BlockNumber, fullTx bool) (map[string]json.RawMessage, error) {
+	block := api.chain.BlockByNumber(api.number(number))
+	if block == nil {
+		return nil, nil
+	}
+
+	return marshalBlock(block, fullTx)
+}
+
+func (api *ethAPI) GetBlockByHash(hash common.Hash, fullTx bool) (map[string]json.RawMessage, error) {
+	block := api.chain.BlockByHash(hash)
+	if block == nil {
+		return nil, nil
+	}
+
+	return marshalBlock(block, fullTx)
+}
+
+func (api *ethAPI) GetTransactionReceipt(hash common.Hash) *types.Receipt {
+	return api.chain.Receipt(hash)
+}
+
+func (api *ethAPI) GetTransactionCount(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
+	number, err := api.blockNrOrHash(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := hexutil.Uint64(api.chain.Nonce(address, number))
+	return &nonce, nil
+}
+
+func (api *ethAPI) GetBalance(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	number, err := api.blockNrOrHash(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return (*hexutil.Big)(api.chain.Balance(address, number)), nil
+}
+
+// callArgs are the args of eth_estimateGas, fees are ignored.
+type callArgs struct {
+	From       *common.Address   `json:"from"`
+	To         *common.Address   `json:"to"`
+	Value      *hexutil.Big      `json:"value"`
+	Data       *hexutil.Bytes    `json:"data"`
+	Input      *hexutil.Bytes    `json:"input"`
+	AccessList *types.AccessList `json:"accessList"`
+}
+
+// EstimateGas returns the intrinsic gas of the call, there are no contracts on the chain.
+func (api *ethAPI) EstimateGas(args callArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	number := api.chain.Head().NumberU64()
+	if blockNrOrHash != nil {
+		var err error
+		if number, err = api.blockNrOrHash(*blockNrOrHash); err != nil {
+			return 0, err
+		}
+	}
+
+	tx := &types.AccessListTx{To: args.To}
+	if args.Input != nil {
+		tx.Data = *args.Input
+	} else if args.Data != nil {
+		tx.Data = *args.Data
+	}
+	if args.AccessList != nil {
+		tx.AccessList = *args.AccessList
+	}
+
+	if args.From != nil && args.Value != nil {
+		if api.chain.Balance(*args.From, number).Cmp(args.Value.ToInt()) < 0 {
+			return 0, errors.New("insufficient funds for transfer")
+		}
+	}
+
+	return hexutil.Uint64(IntrinsicGas(types.NewTx(tx))), nil
+}
+
+func (api *ethAPI) SendBundle(args types.SendBundleArgs) error {
+	if len(args.Txs) == 0 {
+		return errors.New("bundle missing txs")
+	}
+
+	txs := make(types.Transactions, 0, len(args.Txs))
+	for _, encodedTx := range args.Txs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(encodedTx); err != nil {
+			return err
+		}
+		txs = append(txs, tx)
+	}
+
+	api.chain.AddBundle(&types.Bundle{
+		Txs:            txs,
+		MaxBlockNumber: args.MaxBlockNumber,
+	})
+	return nil
+}
+
+// SendRawTransaction includes the tx in the next blocks, it is kept until its nonce is reached.
+func (api *ethAPI) SendRawTransaction(input hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+
+	api.chain.AddBundle(&types.Bundle{Txs: types.Transactions{tx}})
+	return tx.Hash(), nil
+}
+
+// number resolves latest, pending and other block tags to the head.
+func (api *ethAPI) number(number rpc.BlockNumber) uint64 {
+	if number < 0 {
+		return api.chain.Head().NumberU64()
+	}
+
+	return uint64(number)
+}
+
+func (api *ethAPI) blockNrOrHash(blockNrOrHash rpc.BlockNumberOrHash) (uint64, error) {
+	if number, ok := blockNrOrHash.Number(); ok {
+		return api.number(number), nil
+	}
+
+	hash, _ := blockNrOrHash.Hash()
+	block := api.chain.BlockByHash(hash)
+	if block == nil {
+		return 0, errors.New("header not found")
+	}
+
+	return block.NumberU64(), nil
+}
+
+func marshalBlock(block *types.Block, fullTx bool) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(block.Header())
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+
+	txs := make([]interface{}, 0, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		if !fullTx {
+			txs = append(txs, tx.Hash())
+			continue
+		}
+
+		rpcTx, err := marshalTx(block, tx, i)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, rpcTx)
+	}
+
+	if fields["transactions"], err = json.Marshal(txs); err != nil {
+		return nil, err
+	}
+	fields["uncles"] = json.RawMessage("[]")
+
+	return fields, nil
+}
+
+func marshalTx(block *types.Block, tx *types.Transaction, index int) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+
+	from, _ := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	extra := map[string]interface{}{
+		"blockHash":        block.Hash(),
+		"blockNumber":      (*hexutil.Big)(block.Number()),
+		"from":             from,
+		"transactionIndex": hexutil.Uint64(index),
+	}
+
+	for k, v := range extra {
+		if fields[k], err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,559 @@
+package mevtest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+
+	"github.com/bnb-chain/bsc-mev-cases/log"
+)
+
+var (
+	DefaultChainID  = big.NewInt(714)
+	DefaultGasLimit = uint64(140000000)
+)
+
+var (
+	ErrNonceTooLow       = errors.New("nonce too low")
+	ErrNonceTooHigh      = errors.New("nonce too high")
+	ErrInsufficientFunds = errors.New("insufficient funds for gas * price + value")
+	ErrIntrinsicGas      = errors.New("intrinsic gas too low")
+	ErrGasLimitReached   = errors.New("gas limit reached")
+	ErrInvalidReward     = errors.New("invalid reward")
+	ErrGasUsedExceeds    = errors.New("gas used exceeds gas limit")
+	ErrGasUsedMismatch   = errors.New("gas used mismatch")
+)
+
+// ChainConfig configures the chain of the fake validators.
+type ChainConfig struct {
+	ChainID  *big.Int
+	GasLimit uint64
+	// Alloc is the BNB balance of accounts in genesis
+	Alloc map[common.Address]*big.Int
+}
+
+type account struct {
+	balance *big.Int
+	nonce   uint64
+}
+
+// state is the world state after a block, it only tracks BNB balance and nonce.
+type state map[common.Address]*account
+
+func (s state) get(addr common.Address) *account {
+	acc, ok := s[addr]
+	if !ok {
+		acc = &account{balance: new(big.Int)}
+		s[addr] = acc
+	}
+
+	return acc
+}
+
+func (s state) copy() state {
+	cpy := make(state, len(s))
+	for addr, acc := range s {
+		cpy[addr] = &account{balance: new(big.Int).Set(acc.balance), nonce: acc.nonce}
+	}
+
+	return cpy
+}
+
+type txLookup struct {
+	block   *types.Block
+	index   int
+	receipt *types.Receipt
+}
+
+// Chain is an in-memory chain sealed by the fake validators in turn. Txs are not
+// executed by an EVM, every tx only costs its intrinsic gas and transfers its value.
+type Chain struct {
+	mu sync.RWMutex
+	// sealMu is held by Seal, and read locked by the validators receiving bids, so a bid
+	// accepted for the next block is not missed by the sealing one
+	sealMu sync.RWMutex
+
+	config     ChainConfig
+	signer     types.Signer
+	blocks     []*types.Block
+	states     []state
+	txs        map[common.Hash]*txLookup
+	bundles    []*types.Bundle
+	validators []*Server
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewChain creates a chain with the genesis block.
+func NewChain(config ChainConfig) *Chain {
+	if config.ChainID == nil {
+		config.ChainID = DefaultChainID
+	}
+	if config.GasLimit == 0 {
+		config.GasLimit = DefaultGasLimit
+	}
+
+	genesisState := make(state)
+	for addr, balance := range config.Alloc {
+		genesisState.get(addr).balance.Set(balance)
+	}
+
+	genesis := types.NewBlockWithHeader(&types.Header{
+		Number:     big.NewInt(0),
+		GasLimit:   config.GasLimit,
+		Difficulty: big.NewInt(2),
+		Time:       uint64(time.Now().Unix()),
+		UncleHash:  types.EmptyUncleHash,
+		TxHash:     types.EmptyTxsHash,
+		BaseFee:    new(big.Int),
+	})
+
+	return &Chain{
+		config: config,
+		signer: types.LatestSignerForChainID(config.ChainID),
+		blocks: []*types.Block{genesis},
+		states: []state{genesisState},
+		txs:    make(map[common.Hash]*txLookup),
+		stopCh: make(chan struct{}),
+	}
+}
+
+// ChainID returns the chain id.
+func (c *Chain) ChainID() *big.Int {
+	return c.config.ChainID
+}
+
+// Head returns the latest block.
+func (c *Chain) Head() *types.Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.blocks[len(c.blocks)-1]
+}
+
+// BlockByNumber returns the block of the number, nil if not found.
+func (c *Chain) BlockByNumber(number uint64) *types.Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if number >= uint64(len(c.blocks)) {
+		return nil
+	}
+
+	return c.blocks[number]
+}
+
+// BlockByHash returns the block of the hash, nil if not found.
+func (c *Chain) BlockByHash(hash common.Hash) *types.Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for i := len(c.blocks) - 1; i >= 0; i-- {
+		if c.blocks[i].Hash() == hash {
+			return c.blocks[i]
+		}
+	}
+
+	return nil
+}
+
+// Receipt returns the receipt of the tx, nil if the tx is not on chain.
+func (c *Chain) Receipt(hash common.Hash) *types.Receipt {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	lookup, ok := c.txs[hash]
+	if !ok {
+		return nil
+	}
+
+	return lookup.receipt
+}
+
+// Balance returns the balance of the address after the block of the number.
+func (c *Chain) Balance(addr common.Address, number uint64) *big.Int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if number >= uint64(len(c.states)) {
+		number = uint64(len(c.states) - 1)
+	}
+
+	acc, ok := c.states[number][addr]
+	if !ok {
+		return new(big.Int)
+	}
+
+	return new(big.Int).Set(acc.balance)
+}
+
+// Nonce returns the nonce of the address after the block of the number.
+func (c *Chain) Nonce(addr common.Address, number uint64) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if number >= uint64(len(c.states)) {
+		number = uint64(len(c.states) - 1)
+	}
+
+	acc, ok := c.states[number][addr]
+	if !ok {
+		return 0
+	}
+
+	return acc.nonce
+}
+
+// stateAt returns a copy of the state after the block of the number.
+func (c *Chain) stateAt(number uint64) state {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if number >= uint64(len(c.states)) {
+		number = uint64(len(c.states) - 1)
+	}
+
+	return c.states[number].copy()
+}
+
+// AddBundle adds a bundle to be included by the next blocks.
+func (c *Chain) AddBundle(bundle *types.Bundle) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.bundles = append(c.bundles, bundle)
+}
+
+func (c *Chain) addValidator(s *Server) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.validators = append(c.validators, s)
+	// same as parlia, validators seal blocks in the ascending order of addresses
+	sort.Slice(c.validators, func(i, j int) bool {
+		return bytes.Compare(c.validators[i].Validator().Bytes(), c.validators[j].Validator().Bytes()) < 0
+	})
+}
+
+// Validators returns the addresses of the validators, ascending.
+func (c *Chain) Validators() []common.Address {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	validators := make([]common.Address, 0, len(c.validators))
+	for _, v := range c.validators {
+		validators = append(validators, v.Validator())
+	}
+
+	return validators
+}
+
+// inTurn returns the validator in turn to seal the block of the number.
+func (c *Chain) inTurn(number uint64) *Server {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.validators) == 0 {
+		return nil
+	}
+
+	return c.validators[number%uint64(len(c.validators))]
+}
+
+// Start seals a block every period until Stop.
+func (c *Chain) Start(period time.Duration) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := c.Seal(); err != nil {
+					log.Errorw("mevtest: failed to seal block", "err", err)
+				}
+			case <-c.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops sealing blocks started by Start.
+func (c *Chain) Stop() {
+	select {
+	case <-c.stopCh:
+	default:
+		close(c.stopCh)
+	}
+	c.wg.Wait()
+}
+
+// Seal seals the next block by the validator in turn with its best bid which can be
+// applied, followed by the pending bundles.
+func (c *Chain) Seal() (*types.Block, error) {
+	c.sealMu.Lock()
+	defer c.sealMu.Unlock()
+
+	parent := c.Head()
+	number := parent.NumberU64() + 1
+
+	validator := c.inTurn(number)
+	if validator == nil {
+		return nil, errors.New("no validator")
+	}
+
+	var bids []*types.BidArgs
+	if validator.Running() {
+		bids = validator.takeBids(number, parent.Hash())
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   validator.Validator(),
+		Number:     new(big.Int).SetUint64(number),
+		GasLimit:   c.config.GasLimit,
+		Difficulty: big.NewInt(2),
+		Time:       uint64(time.Now().Unix()),
+		BaseFee:    new(big.Int),
+	}
+	if header.Time <= parent.Time() {
+		header.Time = parent.Time() + 1
+	}
+
+	env := &sealEnv{
+		header: header,
+		state:  c.states[len(c.states)-1].copy(),
+	}
+
+	for _, bid := range bids {
+		err := c.applyBid(env, bid)
+		if err == nil {
+			break
+		}
+
+		validator.reportIssue(bid, err)
+	}
+
+	pending := make([]*types.Bundle, 0, len(c.bundles))
+	for _, bundle := range c.bundles {
+		if bundle.MaxBlockNumber != 0 && bundle.MaxBlockNumber < number {
+			continue
+		}
+
+		if err := c.applyTxs(env, bundle.Txs); err != nil {
+			pending = append(pending, bundle)
+		}
+	}
+	c.bundles = pending
+
+	header.GasUsed = env.gasUsed
+	block := types.NewBlock(header, env.txs, nil, env.receipts, trie.NewStackTrie(nil))
+	for i, receipt := range env.receipts {
+		receipt.BlockHash = block.Hash()
+		receipt.BlockNumber = block.Number()
+		receipt.TransactionIndex = uint(i)
+		for _, l := range receipt.Logs {
+			l.BlockHash = block.Hash()
+		}
+
+		c.txs[receipt.TxHash] = &txLookup{block: block, index: i, receipt: receipt}
+	}
+
+	c.blocks = append(c.blocks, block)
+	c.states = append(c.states, env.state)
+
+	log.Debugw("mevtest: sealed block", "number", number, "hash", block.Hash(), "txs", len(env.txs))
+	return block, nil
+}
+
+type sealEnv struct {
+	header   *types.Header
+	state    state
+	gasUsed  uint64
+	txs      []*types.Transaction
+	receipts []*types.Receipt
+}
+
+func (e *sealEnv) copy() *sealEnv {
+	return &sealEnv{
+		header:   e.header,
+		state:    e.state.copy(),
+		gasUsed:  e.gasUsed,
+		txs:      append([]*types.Transaction{}, e.txs...),
+		receipts: append([]*types.Receipt{}, e.receipts...),
+	}
+}
+
+// applyBid applies the txs of the bid and its PayBidTx, env is untouched if failed.
+func (c *Chain) applyBid(env *sealEnv, bid *types.BidArgs) error {
+	// same as the validator, gas of system txs is reserved
+	available := uint64(0)
+	if reserved := params.SystemTxsGas + env.gasUsed; env.header.GasLimit > reserved {
+		available = env.header.GasLimit - reserved
+	}
+	if bid.RawBid.GasUsed > available {
+		return ErrGasUsedExceeds
+	}
+
+	txs, err := bid.RawBid.DecodeTxs(c.signer)
+	if err != nil {
+		return err
+	}
+	bidTxs := len(txs)
+
+	if len(bid.PayBidTx) != 0 {
+		payBidTx := new(types.Transaction)
+		if err = payBidTx.UnmarshalBinary(bid.PayBidTx); err != nil {
+			return err
+		}
+		txs = append(txs, payBidTx)
+	}
+
+	tmp := env.copy()
+	if err = c.applyTxs(tmp, txs); err != nil {
+		return err
+	}
+
+	// gasUsed of the bid is the gas used by its txs, PayBidTx excluded
+	gasUsed := uint64(0)
+	for _, receipt := range tmp.receipts[len(env.receipts) : len(env.receipts)+bidTxs] {
+		gasUsed += receipt.GasUsed
+	}
+	if gasUsed != bid.RawBid.GasUsed {
+		return fmt.Errorf("%w, expected %v, got %v", ErrGasUsedMismatch, bid.RawBid.GasUsed, gasUsed)
+	}
+
+	fees := new(big.Int)
+	for _, receipt := range tmp.receipts[len(env.receipts):] {
+		fee := new(big.Int).SetUint64(receipt.GasUsed)
+		fees.Add(fees, fee.Mul(fee, receipt.EffectiveGasPrice))
+	}
+
+	if bid.RawBid.GasFee != nil && fees.Cmp(bid.RawBid.GasFee) < 0 {
+		return fmt.Errorf("%w, expected %v, got %v", ErrInvalidReward, bid.RawBid.GasFee, fees)
+	}
+
+	*env = *tmp
+	return nil
+}
+
+// applyTxs applies all the txs or none of them.
+func (c *Chain) applyTxs(env *sealEnv, txs []*types.Transaction) error {
+	tmp := env.copy()
+	for _, tx := range txs {
+		receipt, err := c.applyTx(tmp, tx)
+		if err != nil {
+			return fmt.Errorf("tx %v: %w", tx.Hash(), err)
+		}
+
+		tmp.txs = append(tmp.txs, tx)
+		tmp.receipts = append(tmp.receipts, receipt)
+	}
+
+	*env = *tmp
+	return nil
+}
+
+func (c *Chain) applyTx(env *sealEnv, tx *types.Transaction) (*types.Receipt, error) {
+	from, err := types.Sender(c.signer, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	sender := env.state.get(from)
+	if tx.Nonce() < sender.nonce {
+		return nil, ErrNonceTooLow
+	}
+	if tx.Nonce() > sender.nonce {
+		return nil, ErrNonceTooHigh
+	}
+
+	gas := IntrinsicGas(tx)
+	if tx.Gas() < gas {
+		return nil, ErrIntrinsicGas
+	}
+	if env.gasUsed+gas > env.header.GasLimit {
+		return nil, ErrGasLimitReached
+	}
+
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasFeeCap())
+	cost.Add(cost, tx.Value())
+	if sender.balance.Cmp(cost) < 0 {
+		return nil, ErrInsufficientFunds
+	}
+
+	price := EffectiveGasPrice(tx, env.header.BaseFee)
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(gas), price)
+
+	sender.balance.Sub(sender.balance, fee)
+	sender.balance.Sub(sender.balance, tx.Value())
+	sender.nonce++
+	if tx.To() != nil {
+		to := env.state.get(*tx.To())
+		to.balance.Add(to.balance, tx.Value())
+	}
+	coinbase := env.state.get(env.header.Coinbase)
+	coinbase.balance.Add(coinbase.balance, fee)
+
+	env.gasUsed += gas
+
+	return &types.Receipt{
+		Type:              tx.Type(),
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: env.gasUsed,
+		Logs:              []*types.Log{},
+		TxHash:            tx.Hash(),
+		GasUsed:           gas,
+		EffectiveGasPrice: price,
+	}, nil
+}
+
+// IntrinsicGas returns the gas used by the tx on the fake chain.
+func IntrinsicGas(tx *types.Transaction) uint64 {
+	gas := params.TxGas
+	if tx.To() == nil {
+		gas = params.TxGasContractCreation
+	}
+
+	for _, b := range tx.Data() {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGasEIP2028
+		}
+	}
+
+	for _, tuple := range tx.AccessList() {
+		gas += params.TxAccessListAddressGas
+		gas += uint64(len(tuple.StorageKeys)) * params.TxAccessListStorageKeyGas
+	}
+
+	return gas
+}
+
+// EffectiveGasPrice returns the gas price paid by the tx under the base fee.
+func EffectiveGasPrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return new(big.Int).Set(tx.GasPrice())
+	}
+
+	tip := tx.EffectiveGasTipValue(baseFee)
+	return tip.Add(tip, baseFee)
+}
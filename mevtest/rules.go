@@ -0,0 +1,175 @@
+package mevtest
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TransferTxGasLimit is the max PayBidTxGasUsed accepted, same as the validator.
+const TransferTxGasLimit = 25000
+
+// BidError is a json-rpc error returned by mev_sendBid.
+type BidError struct {
+	Code    int
+	Message string
+}
+
+// NewBidError creates a BidError of the code.
+func NewBidError(code int, format string, args ...interface{}) *BidError {
+	return &BidError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (e *BidError) Error() string {
+	return e.Message
+}
+
+// ErrorCode implements rpc.Error.
+func (e *BidError) ErrorCode() int {
+	return e.Code
+}
+
+// BidEnv is what a rule checks a bid against.
+type BidEnv struct {
+	// Head is the latest block when the bid is received
+	Head *types.Header
+	// Signer decodes the txs of the bid
+	Signer types.Signer
+	// Builders are the registered builders, any builder is accepted if empty
+	Builders []common.Address
+}
+
+// Rule checks a bid received by mev_sendBid, it returns an error to reject the bid.
+// Errors implementing rpc.Error are returned to the builder with their code.
+type Rule func(env *BidEnv, args *types.BidArgs) error
+
+// DefaultRules returns the rules checked by the validator, in the same order.
+func DefaultRules() []Rule {
+	return []Rule{
+		CheckRawBid,
+		CheckBlock,
+		CheckGas,
+		CheckBuilderFee,
+		CheckSignature,
+		CheckTxs,
+	}
+}
+
+// WithCode replaces the code of the error returned by the rule.
+func WithCode(rule Rule, code int) Rule {
+	return func(env *BidEnv, args *types.BidArgs) error {
+		err := rule(env, args)
+		if err == nil {
+			return nil
+		}
+
+		return NewBidError(code, "%v", err)
+	}
+}
+
+// CheckRawBid rejects bids without RawBid.
+func CheckRawBid(_ *BidEnv, args *types.BidArgs) error {
+	if args.RawBid == nil {
+		return NewBidError(types.InvalidBidParamError, "rawBid should not be nil")
+	}
+
+	return nil
+}
+
+// CheckBlock rejects bids not for the next block of the head.
+func CheckBlock(env *BidEnv, args *types.BidArgs) error {
+	rawBid := args.RawBid
+	if rawBid.BlockNumber != env.Head.Number.Uint64()+1 {
+		return NewBidError(types.InvalidBidParamError, "stale block number or block in future")
+	}
+
+	if rawBid.ParentHash != env.Head.Hash() {
+		return NewBidError(types.InvalidBidParamError, "non-aligned parent hash: %v", env.Head.Hash())
+	}
+
+	return nil
+}
+
+// CheckGas rejects bids with empty gasFee or gasUsed.
+func CheckGas(_ *BidEnv, args *types.BidArgs) error {
+	rawBid := args.RawBid
+	if rawBid.GasFee == nil || rawBid.GasFee.Sign() == 0 || rawBid.GasUsed == 0 {
+		return NewBidError(types.InvalidBidParamError, "empty gasFee or empty gasUsed")
+	}
+
+	return nil
+}
+
+// CheckBuilderFee rejects bids whose builderFee and PayBidTx are not aligned.
+func CheckBuilderFee(_ *BidEnv, args *types.BidArgs) error {
+	builderFee := args.RawBid.BuilderFee
+	if builderFee == nil {
+		if len(args.PayBidTx) != 0 || args.PayBidTxGasUsed != 0 {
+			return NewBidError(types.InvalidPayBidTxError, "payBidTx should be nil when builder fee is nil")
+		}
+
+		return nil
+	}
+
+	if builderFee.Sign() < 0 {
+		return NewBidError(types.InvalidBidParamError, "builder fee should not be less than 0")
+	}
+
+	if builderFee.Sign() == 0 {
+		if len(args.PayBidTx) != 0 || args.PayBidTxGasUsed != 0 {
+			return NewBidError(types.InvalidPayBidTxError, "payBidTx should be nil when builder fee is 0")
+		}
+	}
+
+	if builderFee.Cmp(args.RawBid.GasFee) >= 0 {
+		return NewBidError(types.InvalidBidParamError, "builder fee must be less than gas fee")
+	}
+
+	if builderFee.Sign() > 0 {
+		if args.PayBidTxGasUsed > TransferTxGasLimit {
+			return NewBidError(types.InvalidBidParamError,
+				"transfer tx gas used must be no more than %v", TransferTxGasLimit)
+		}
+
+		if (len(args.PayBidTx) == 0 && args.PayBidTxGasUsed != 0) ||
+			(len(args.PayBidTx) != 0 && args.PayBidTxGasUsed == 0) {
+			return NewBidError(types.InvalidPayBidTxError, "non-aligned payBidTx and payBidTxGasUsed")
+		}
+	}
+
+	return nil
+}
+
+// CheckSignature rejects bids not signed by a registered builder.
+func CheckSignature(env *BidEnv, args *types.BidArgs) error {
+	builder, err := args.EcrecoverSender()
+	if err != nil {
+		return NewBidError(types.InvalidBidParamError, "invalid signature:%v", err)
+	}
+
+	if len(env.Builders) == 0 {
+		return nil
+	}
+
+	for _, b := range env.Builders {
+		if b == builder {
+			return nil
+		}
+	}
+
+	return NewBidError(types.InvalidBidParamError, "builder is not registered")
+}
+
+// CheckTxs rejects bids with txs or PayBidTx can not be decoded.
+func CheckTxs(env *BidEnv, args *types.BidArgs) error {
+	_, err := args.ToBid(common.Address{}, env.Signer)
+	if err != nil {
+		return NewBidError(types.InvalidBidParamError, "fail to convert bidArgs to bid, %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,323 @@
+package mevtest
+
+import (
+	"math/big"
+	"net/http/httptest"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/bnb-chain/bsc-mev-cases/log"
+)
+
+// DefaultMaxBidsPerBuilder is the max bids accepted from a builder for a block, same as the validator.
+const DefaultMaxBidsPerBuilder = 3
+
+// Config configures a fake validator.
+type Config struct {
+	// Validator is the coinbase of the blocks sealed by the validator
+	Validator common.Address
+	// Builders are the registered builders, any builder is accepted if empty
+	Builders []common.Address
+	// Rules check the bids received, DefaultRules is used if nil
+	Rules []Rule
+	// MaxBidsPerBuilder is the max bids accepted from a builder for a block
+	MaxBidsPerBuilder int
+	Params            types.MevParams
+}
+
+type receivedBid struct {
+	args    *types.BidArgs
+	builder common.Address
+	hash    common.Hash
+}
+
+// reward is the reward of the validator claimed by the bid.
+func (b *receivedBid) reward() *big.Int {
+	reward := new(big.Int).Set(b.args.RawBid.GasFee)
+	if b.args.RawBid.BuilderFee != nil {
+		reward.Sub(reward, b.args.RawBid.BuilderFee)
+	}
+
+	return reward
+}
+
+// Server is a fake validator serving the mev and eth json-rpc api over http.
+type Server struct {
+	URL string
+
+	chain  *Chain
+	config Config
+	http   *httptest.Server
+
+	mu       sync.Mutex
+	running  bool
+	inTurn   *bool
+	injected []error
+	bids     map[uint64][]*receivedBid
+	issues   []types.BidIssue
+}
+
+// NewServer starts a fake validator sealing blocks of the chain in turn.
+func NewServer(chain *Chain, config Config) *Server {
+	if config.Rules == nil {
+		config.Rules = DefaultRules()
+	}
+	if config.MaxBidsPerBuilder == 0 {
+		config.MaxBidsPerBuilder = DefaultMaxBidsPerBuilder
+	}
+
+	s := &Server{
+		chain:   chain,
+		config:  config,
+		running: true,
+		bids:    make(map[uint64][]*receivedBid),
+	}
+
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("mev", &mevAPI{s}); err != nil {
+		log.Panicw("mevtest: failed to register mev api", "err", err)
+	}
+	if err := srv.RegisterName("eth", &ethAPI{chain}); err != nil {
+		log.Panicw("mevtest: failed to register eth api", "err", err)
+	}
+	if err := srv.RegisterName("parlia", &parliaAPI{chain}); err != nil {
+		log.Panicw("mevtest: failed to register parlia api", "err", err)
+	}
+	if err := srv.RegisterName("debug", &debugAPI{chain}); err != nil {
+		log.Panicw("mevtest: failed to register debug api", "err", err)
+	}
+
+	s.http = httptest.NewServer(srv)
+	s.URL = s.http.URL
+
+	chain.addValidator(s)
+	return s
+}
+
+// Close shuts down the http server.
+func (s *Server) Close() {
+	s.http.Close()
+}
+
+// Chain returns the chain sealed by the validator.
+func (s *Server) Chain() *Chain {
+	return s.chain
+}
+
+// Validator returns the validator address.
+func (s *Server) Validator() common.Address {
+	return s.config.Validator
+}
+
+// Running reports whether the validator accepts bids.
+func (s *Server) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.running
+}
+
+// SetRunning sets whether the validator accepts bids.
+func (s *Server) SetRunning(running bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running = running
+}
+
+// InTurn reports whether the validator is in turn to seal the next block.
+func (s *Server) InTurn() bool {
+	s.mu.Lock()
+	inTurn := s.inTurn
+	s.mu.Unlock()
+
+	if inTurn != nil {
+		return *inTurn
+	}
+
+	return s.chain.inTurn(s.chain.Head().NumberU64()+1) == s
+}
+
+// SetInTurn overrides whether the validator reports itself in turn.
+func (s *Server) SetInTurn(inTurn bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inTurn = &inTurn
+}
+
+// InjectError makes the next mev_sendBid return the error, errors are returned in the
+// order injected. Use NewBidError to return an error with code.
+func (s *Server) InjectError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.injected = append(s.injected, err)
+}
+
+// Bids returns the bids accepted for the block number and not sealed yet.
+func (s *Server) Bids(blockNumber uint64) []*types.BidArgs {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bids := make([]*types.BidArgs, 0, len(s.bids[blockNumber]))
+	for _, b := range s.bids[blockNumber] {
+		bids = append(bids, b.args)
+	}
+
+	return bids
+}
+
+// Issues returns the issues of the bids failed to be sealed.
+func (s *Server) Issues() []types.BidIssue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]types.BidIssue{}, s.issues...)
+}
+
+func (s *Server) sendBid(args *types.BidArgs) (common.Hash, error) {
+	s.mu.Lock()
+	if len(s.injected) > 0 {
+		err := s.injected[0]
+		s.injected = s.injected[1:]
+		s.mu.Unlock()
+		return common.Hash{}, err
+	}
+	running := s.running
+	s.mu.Unlock()
+
+	if !running {
+		return common.Hash{}, types.ErrMevNotRunning
+	}
+
+	s.chain.sealMu.RLock()
+	defer s.chain.sealMu.RUnlock()
+
+	if !s.InTurn() {
+		return common.Hash{}, types.ErrMevNotInTurn
+	}
+
+	env := &BidEnv{
+		Head:     s.chain.Head().Header(),
+		Signer:   s.chain.signer,
+		Builders: s.config.Builders,
+	}
+
+	for _, rule := range s.config.Rules {
+		if err := rule(env, args); err != nil {
+			return common.Hash{}, err
+		}
+	}
+
+	builder, err := args.EcrecoverSender()
+	if err != nil {
+		return common.Hash{}, NewBidError(types.InvalidBidParamError, "invalid signature:%v", err)
+	}
+
+	bid := &receivedBid{
+		args:    args,
+		builder: builder,
+		hash:    args.RawBid.Hash(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	number := args.RawBid.BlockNumber
+	for n := range s.bids {
+		if n < number {
+			delete(s.bids, n)
+		}
+	}
+
+	count := 0
+	for _, b := range s.bids[number] {
+		if b.hash == bid.hash {
+			return common.Hash{}, NewBidError(types.InvalidBidParamError, "bid already exists")
+		}
+		if b.builder == builder {
+			count++
+		}
+	}
+
+	if count >= s.config.MaxBidsPerBuilder {
+		return common.Hash{}, NewBidError(types.InvalidBidParamError, "too many bids")
+	}
+
+	s.bids[number] = append(s.bids[number], bid)
+	return bid.hash, nil
+}
+
+// bestBid returns the bid of the highest reward on the parent.
+func (s *Server) bestBid(parentHash common.Hash) *receivedBid {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var best *receivedBid
+	for _, bids := range s.bids {
+		for _, b := range bids {
+			if b.args.RawBid.ParentHash != parentHash {
+				continue
+			}
+
+			if best == nil || b.reward().Cmp(best.reward()) > 0 {
+				best = b
+			}
+		}
+	}
+
+	return best
+}
+
+// takeBids removes the bids up to the block number, and returns those on the parent
+// ordered by reward, the best first.
+func (s *Server) takeBids(number uint64, parentHash common.Hash) []*types.BidArgs {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	candidates := make([]*receivedBid, 0)
+	for n, bids := range s.bids {
+		if n > number {
+			continue
+		}
+
+		for _, b := range bids {
+			if n == number && b.args.RawBid.ParentHash == parentHash {
+				candidates = append(candidates, b)
+			}
+		}
+		delete(s.bids, n)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].reward().Cmp(candidates[j].reward()) > 0
+	})
+
+	bids := make([]*types.BidArgs, 0, len(candidates))
+	for _, b := range candidates {
+		bids = append(bids, b.args)
+	}
+
+	return bids
+}
+
+func (s *Server) reportIssue(args *types.BidArgs, err error) {
+	builder, _ := args.EcrecoverSender()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.issues = append(s.issues, types.BidIssue{
+		Validator: s.config.Validator,
+		Builder:   builder,
+		BidHash:   args.RawBid.Hash(),
+		Message:   err.Error(),
+	})
+
+	log.Infow("mevtest: bid failed", "builder", builder, "bidHash", args.RawBid.Hash(), "err", err)
+}
@@ -0,0 +1,251 @@
+package mevtest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testGasPrice = big.NewInt(1e11)
+	testBalance  = new(big.Int).Mul(big.NewInt(1e18), big.NewInt(100))
+)
+
+type testEnv struct {
+	chain      *Chain
+	server     *Server
+	client     *ethclient.Client
+	userKey    *ecdsa.PrivateKey
+	builderKey *ecdsa.PrivateKey
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	userKey, _ := crypto.GenerateKey()
+	builderKey, _ := crypto.GenerateKey()
+	user := crypto.PubkeyToAddress(userKey.PublicKey)
+
+	chain := NewChain(ChainConfig{
+		Alloc: map[common.Address]*big.Int{user: testBalance},
+	})
+	server := NewServer(chain, Config{
+		Validator: common.HexToAddress("0x01"),
+		Builders:  []common.Address{crypto.PubkeyToAddress(builderKey.PublicKey)},
+	})
+	t.Cleanup(server.Close)
+
+	client, err := ethclient.Dial(server.URL)
+	assert.Nil(t, err)
+
+	return &testEnv{
+		chain:      chain,
+		server:     server,
+		client:     client,
+		userKey:    userKey,
+		builderKey: builderKey,
+	}
+}
+
+func (e *testEnv) transfers(t *testing.T, nonce uint64, count int) []*types.Transaction {
+	txs := make([]*types.Transaction, 0, count)
+	for i := 0; i < count; i++ {
+		to := common.HexToAddress("0x02")
+		tx, err := types.SignNewTx(e.userKey, types.LatestSignerForChainID(e.chain.ChainID()), &types.LegacyTx{
+			Nonce:    nonce + uint64(i),
+			To:       &to,
+			Value:    big.NewInt(1),
+			Gas:      21000,
+			GasPrice: testGasPrice,
+		})
+		assert.Nil(t, err)
+		txs = append(txs, tx)
+	}
+
+	return txs
+}
+
+func (e *testEnv) bid(t *testing.T, txs []*types.Transaction, gasFee *big.Int) *types.BidArgs {
+	head := e.chain.Head()
+	rawBid := &types.RawBid{
+		BlockNumber: head.NumberU64() + 1,
+		ParentHash:  head.Hash(),
+		GasUsed:     uint64(21000 * len(txs)),
+		GasFee:      gasFee,
+	}
+
+	for _, tx := range txs {
+		b, err := tx.MarshalBinary()
+		assert.Nil(t, err)
+		rawBid.Txs = append(rawBid.Txs, b)
+	}
+
+	data, err := rlp.EncodeToBytes(rawBid)
+	assert.Nil(t, err)
+	sig, err := crypto.Sign(crypto.Keccak256(data), e.builderKey)
+	assert.Nil(t, err)
+
+	return &types.BidArgs{RawBid: rawBid, Signature: sig}
+}
+
+func assertErrorCode(t *testing.T, err error, code int) {
+	rpcErr, ok := err.(rpc.Error)
+	if assert.True(t, ok, "expect rpc error, got %v", err) {
+		assert.Equal(t, code, rpcErr.ErrorCode(), rpcErr.Error())
+	}
+}
+
+func TestSendBidAndSeal(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	txs := env.transfers(t, 0, 3)
+	gasFee := new(big.Int).Mul(big.NewInt(21000*3), testGasPrice)
+	bid := env.bid(t, txs, gasFee)
+
+	hash, err := env.client.SendBid(ctx, *bid)
+	assert.Nil(t, err)
+	assert.Equal(t, bid.RawBid.Hash(), hash)
+
+	fee, err := env.client.BestBidGasFee(ctx, env.chain.Head().Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, gasFee, fee)
+
+	_, err = env.chain.Seal()
+	assert.Nil(t, err)
+
+	block, err := env.client.BlockByNumber(ctx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), block.NumberU64())
+	assert.Len(t, block.Transactions(), 3)
+	assert.Equal(t, env.server.Validator(), block.Coinbase())
+
+	for _, tx := range txs {
+		receipt, err := env.client.TransactionReceipt(ctx, tx.Hash())
+		assert.Nil(t, err)
+		assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+		assert.Equal(t, block.Hash(), receipt.BlockHash)
+	}
+
+	nonce, err := env.client.PendingNonceAt(ctx, crypto.PubkeyToAddress(env.userKey.PublicKey))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), nonce)
+
+	balance, err := env.client.BalanceAt(ctx, env.server.Validator(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, gasFee, balance)
+}
+
+func TestSendBidRules(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	gasFee := new(big.Int).Mul(big.NewInt(21000), testGasPrice)
+
+	bid := env.bid(t, env.transfers(t, 0, 1), gasFee)
+	bid.RawBid.BlockNumber += 10
+	_, err := env.client.SendBid(ctx, *bid)
+	assertErrorCode(t, err, types.InvalidBidParamError)
+
+	bid = env.bid(t, env.transfers(t, 0, 1), gasFee)
+	bid.Signature = hexutil.Bytes("invalid signature")
+	_, err = env.client.SendBid(ctx, *bid)
+	assertErrorCode(t, err, types.InvalidBidParamError)
+
+	bid = env.bid(t, env.transfers(t, 0, 1), gasFee)
+	bid.PayBidTxGasUsed = 21000
+	_, err = env.client.SendBid(ctx, *bid)
+	assertErrorCode(t, err, types.InvalidPayBidTxError)
+
+	env.server.SetInTurn(false)
+	_, err = env.client.SendBid(ctx, *env.bid(t, env.transfers(t, 0, 1), gasFee))
+	assertErrorCode(t, err, types.MevNotInTurnError)
+
+	env.server.SetInTurn(true)
+	env.server.InjectError(NewBidError(types.MevBusyError, "busy"))
+	_, err = env.client.SendBid(ctx, *env.bid(t, env.transfers(t, 0, 1), gasFee))
+	assertErrorCode(t, err, types.MevBusyError)
+}
+
+func TestSealInvalidReward(t *testing.T) {
+	env := newTestEnv(t)
+
+	txs := env.transfers(t, 0, 2)
+	gasFee := new(big.Int).Mul(big.NewInt(21000*3), testGasPrice)
+	_, err := env.client.SendBid(context.Background(), *env.bid(t, txs, gasFee))
+	assert.Nil(t, err)
+
+	block, err := env.chain.Seal()
+	assert.Nil(t, err)
+	assert.Empty(t, block.Transactions())
+
+	issues := env.server.Issues()
+	if assert.Len(t, issues, 1) {
+		assert.Contains(t, issues[0].Message, ErrInvalidReward.Error())
+	}
+}
+
+func TestEstimateGas(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user := crypto.PubkeyToAddress(env.userKey.PublicKey)
+	to := common.HexToAddress("0x02")
+
+	gas, err := env.client.EstimateGas(ctx, ethereum.CallMsg{From: user, To: &to, Value: big.NewInt(1)})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(21000), gas)
+
+	gas, err = env.client.EstimateGas(ctx, ethereum.CallMsg{
+		From:       user,
+		To:         &to,
+		Data:       []byte{0, 1},
+		AccessList: types.AccessList{{Address: to, StorageKeys: []common.Hash{{}}}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(21000+4+16+2400+1900), gas)
+
+	_, err = env.client.EstimateGas(ctx, ethereum.CallMsg{From: user, To: &to, Value: new(big.Int).Add(testBalance, big.NewInt(1))})
+	assert.NotNil(t, err)
+}
+
+func TestTraceCall(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user := crypto.PubkeyToAddress(env.userKey.PublicKey)
+	to := common.HexToAddress("0x02")
+
+	args := map[string]interface{}{"from": user, "to": to, "value": (*hexutil.Big)(big.NewInt(1)),
+		"gas": hexutil.Uint64(21000), "gasPrice": (*hexutil.Big)(testGasPrice)}
+	config := map[string]interface{}{
+		"tracer": "muxTracer",
+		"tracerConfig": map[string]interface{}{
+			"callTracer":     map[string]interface{}{},
+			"prestateTracer": map[string]interface{}{"diffMode": true},
+		},
+	}
+
+	var res muxResult
+	assert.Nil(t, env.client.Client().CallContext(ctx, &res, "debug_traceCall", args, "latest", config))
+	assert.Equal(t, hexutil.Uint64(21000), res.CallTracer.GasUsed)
+
+	spent := new(big.Int).Add(new(big.Int).Mul(big.NewInt(21000), testGasPrice), big.NewInt(1))
+	assert.Equal(t, new(big.Int).Sub(testBalance, spent), res.PrestateTracer.Post[user].Balance.ToInt())
+	assert.Equal(t, uint64(1), res.PrestateTracer.Post[user].Nonce)
+	assert.Equal(t, big.NewInt(1), res.PrestateTracer.Post[to].Balance.ToInt())
+
+	// on the state overridden
+	config["stateOverrides"] = map[common.Address]interface{}{user: map[string]interface{}{"balance": "0x0"}}
+	err := env.client.Client().CallContext(ctx, &res, "debug_traceCall", args, "latest", config)
+	assert.ErrorContains(t, err, ErrInsufficientFunds.Error())
+
+	err = env.client.Client().CallContext(ctx, &res, "debug_traceCall", args, "latest", map[string]interface{}{})
+	assert.ErrorContains(t, err, "only muxTracer")
+}
@@ -0,0 +1,147 @@
+package mevtest
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"github.com/bnb-chain/bsc-mev-cases/log"
+)
+
+// BidContentType is the content type of account_signData signing the rlp of a raw bid, the same
+// as cases.BidContentType.
+const BidContentType = "application/x-bsc-bid"
+
+// SignerServer is a stand-in of an external signer like clef, it serves account_list,
+// account_signData and account_signTransaction over http with keys in memory.
+type SignerServer struct {
+	URL string
+
+	http      *httptest.Server
+	keys      map[common.Address]*ecdsa.PrivateKey
+	addresses []common.Address
+
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+// NewSignerServer starts a signer of the keys.
+func NewSignerServer(keys ...*ecdsa.PrivateKey) *SignerServer {
+	s := &SignerServer{
+		keys:     make(map[common.Address]*ecdsa.PrivateKey),
+		requests: make(map[string]int),
+	}
+
+	for _, key := range keys {
+		address := crypto.PubkeyToAddress(key.PublicKey)
+		s.keys[address] = key
+		s.addresses = append(s.addresses, address)
+	}
+
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("account", &accountAPI{s}); err != nil {
+		log.Panicw("mevtest: failed to register account api", "err", err)
+	}
+
+	s.http = httptest.NewServer(srv)
+	s.URL = s.http.URL
+	return s
+}
+
+// Close shuts down the http server.
+func (s *SignerServer) Close() {
+	s.http.Close()
+}
+
+// Requests returns the number of requests of the method, e.g. account_signData.
+func (s *SignerServer) Requests(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[method]
+}
+
+// SetKey replaces the key of the account, e.g. to sign by another key than the account's.
+func (s *SignerServer) SetKey(address common.Address, key *ecdsa.PrivateKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[address] = key
+}
+
+func (s *SignerServer) key(method string, address common.Address) (*ecdsa.PrivateKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests[method]++
+	key, ok := s.keys[address]
+	if !ok {
+		return nil, fmt.Errorf("unknown account %v", address)
+	}
+
+	return key, nil
+}
+
+type accountAPI struct {
+	s *SignerServer
+}
+
+func (api *accountAPI) List() []common.Address {
+	return api.s.addresses
+}
+
+func (api *accountAPI) SignData(contentType string, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
+	key, err := api.s.key("account_signData", addr.Address())
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != BidContentType {
+		return nil, fmt.Errorf("unsupported content type %q", contentType)
+	}
+
+	var rawBid types.RawBid
+	if err = rlp.DecodeBytes(data, &rawBid); err != nil {
+		return nil, fmt.Errorf("invalid raw bid, %v", err)
+	}
+
+	return crypto.Sign(crypto.Keccak256(data), key)
+}
+
+type signTransactionResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+func (api *accountAPI) SignTransaction(args apitypes.SendTxArgs) (*signTransactionResult, error) {
+	key, err := api.s.key("account_signTransaction", args.From.Address())
+	if err != nil {
+		return nil, err
+	}
+
+	if args.ChainID == nil {
+		return nil, errors.New("chain id not set")
+	}
+
+	tx, err := types.SignTx(args.ToTransaction(), types.LatestSignerForChainID(args.ChainID.ToInt()), key)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return &signTransactionResult{Raw: raw, Tx: tx}, nil
+}
@@ -0,0 +1,197 @@
+package mevtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type debugAPI struct {
+	chain *Chain
+}
+
+// traceCallArgs are the args of debug_traceCall.
+type traceCallArgs struct {
+	callArgs
+	Gas                  *hexutil.Uint64 `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+}
+
+// overrideAccount is the state override of an account, only the balance and nonce are tracked.
+type overrideAccount struct {
+	Nonce   *hexutil.Uint64 `json:"nonce"`
+	Balance *hexutil.Big    `json:"balance"`
+}
+
+type traceCallConfig struct {
+	Tracer         string                             `json:"tracer"`
+	TracerConfig   map[string]json.RawMessage         `json:"tracerConfig"`
+	StateOverrides map[common.Address]overrideAccount `json:"stateOverrides"`
+}
+
+type callFrame struct {
+	Type    string          `json:"type"`
+	From    common.Address  `json:"from"`
+	To      *common.Address `json:"to,omitempty"`
+	Value   *hexutil.Big    `json:"value,omitempty"`
+	Gas     hexutil.Uint64  `json:"gas"`
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Input   hexutil.Bytes   `json:"input"`
+}
+
+type prestateAccount struct {
+	Balance *hexutil.Big `json:"balance,omitempty"`
+	Nonce   uint64       `json:"nonce,omitempty"`
+}
+
+type prestateDiff struct {
+	Pre  map[common.Address]*prestateAccount `json:"pre"`
+	Post map[common.Address]*prestateAccount `json:"post"`
+}
+
+type muxResult struct {
+	CallTracer     *callFrame    `json:"callTracer"`
+	PrestateTracer *prestateDiff `json:"prestateTracer"`
+}
+
+// TraceCall executes the call on the state of the block with the overrides, as the muxTracer of
+// a callTracer and a prestateTracer in diff mode, the only tracer supported.
+func (api *debugAPI) TraceCall(args traceCallArgs, blockNrOrHash rpc.BlockNumberOrHash, config *traceCallConfig) (*muxResult, error) {
+	if config == nil || config.Tracer != "muxTracer" {
+		return nil, errors.New("mevtest: only muxTracer is supported")
+	}
+	if _, ok := config.TracerConfig["callTracer"]; !ok {
+		return nil, errors.New("mevtest: callTracer of muxTracer missing")
+	}
+	var prestateConfig struct {
+		DiffMode bool `json:"diffMode"`
+	}
+	if err := json.Unmarshal(config.TracerConfig["prestateTracer"], &prestateConfig); err != nil || !prestateConfig.DiffMode {
+		return nil, errors.New("mevtest: prestateTracer in diff mode of muxTracer missing")
+	}
+
+	number, err := (&ethAPI{api.chain}).blockNrOrHash(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+
+	st := api.chain.stateAt(number)
+	for addr, override := range config.StateOverrides {
+		acc := st.get(addr)
+		if override.Balance != nil {
+			acc.balance.Set(override.Balance.ToInt())
+		}
+		if override.Nonce != nil {
+			acc.nonce = uint64(*override.Nonce)
+		}
+	}
+
+	return traceCall(st, api.chain.BlockByNumber(number).BaseFee(), args)
+}
+
+// traceCall applies the call to the state as applyTx, without the nonce check of a call.
+func traceCall(st state, baseFee *big.Int, args traceCallArgs) (*muxResult, error) {
+	from := common.Address{}
+	if args.From != nil {
+		from = *args.From
+	}
+
+	value := new(big.Int)
+	if args.Value != nil {
+		value = args.Value.ToInt()
+	}
+
+	data := hexutil.Bytes{}
+	if args.Input != nil {
+		data = *args.Input
+	} else if args.Data != nil {
+		data = *args.Data
+	}
+
+	gasLimit := DefaultGasLimit
+	if args.Gas != nil {
+		gasLimit = uint64(*args.Gas)
+	}
+
+	var accessList types.AccessList
+	if args.AccessList != nil {
+		accessList = *args.AccessList
+	}
+
+	var tx *types.Transaction
+	if args.MaxFeePerGas != nil {
+		tip := new(big.Int)
+		if args.MaxPriorityFeePerGas != nil {
+			tip = args.MaxPriorityFeePerGas.ToInt()
+		}
+		tx = types.NewTx(&types.DynamicFeeTx{To: args.To, Gas: gasLimit, GasFeeCap: args.MaxFeePerGas.ToInt(),
+			GasTipCap: tip, Value: value, Data: data, AccessList: accessList})
+	} else {
+		price := new(big.Int)
+		if args.GasPrice != nil {
+			price = args.GasPrice.ToInt()
+		}
+		tx = types.NewTx(&types.AccessListTx{To: args.To, Gas: gasLimit, GasPrice: price, Value: value,
+			Data: data, AccessList: accessList})
+	}
+
+	gas := IntrinsicGas(tx)
+	if gasLimit < gas {
+		return nil, fmt.Errorf("%w: have %v, want %v", ErrIntrinsicGas, gasLimit, gas)
+	}
+
+	sender := st.get(from)
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), tx.GasFeeCap())
+	cost.Add(cost, value)
+	if sender.balance.Cmp(cost) < 0 {
+		return nil, fmt.Errorf("%w: address %v have %v want %v", ErrInsufficientFunds, from, sender.balance, cost)
+	}
+
+	diff := &prestateDiff{
+		Pre:  make(map[common.Address]*prestateAccount),
+		Post: make(map[common.Address]*prestateAccount),
+	}
+	touched := []common.Address{from}
+	if args.To != nil && *args.To != from {
+		touched = append(touched, *args.To)
+	}
+	for _, addr := range touched {
+		acc := st.get(addr)
+		diff.Pre[addr] = &prestateAccount{Balance: (*hexutil.Big)(new(big.Int).Set(acc.balance)), Nonce: acc.nonce}
+	}
+
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(gas), EffectiveGasPrice(tx, baseFee))
+	sender.balance.Sub(sender.balance, fee)
+	sender.balance.Sub(sender.balance, value)
+	sender.nonce++
+	if args.To != nil {
+		to := st.get(*args.To)
+		to.balance.Add(to.balance, value)
+	}
+
+	for _, addr := range touched {
+		acc := st.get(addr)
+		diff.Post[addr] = &prestateAccount{Balance: (*hexutil.Big)(acc.balance), Nonce: acc.nonce}
+	}
+
+	return &muxResult{
+		CallTracer: &callFrame{
+			Type:    "CALL",
+			From:    from,
+			To:      args.To,
+			Value:   (*hexutil.Big)(value),
+			Gas:     hexutil.Uint64(gasLimit),
+			GasUsed: hexutil.Uint64(gas),
+			Input:   data,
+		},
+		PrestateTracer: diff,
+	}, nil
+}
@@ -1,25 +1,141 @@
 package utils
 
 import (
-	"io/ioutil"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
-func PrivateKeyFromKeystore(secretKeyFile string, password string, outPath string) {
-	keyjson, e := ioutil.ReadFile(secretKeyFile)
-	if e != nil {
-		panic(e)
+// KeyPassword is where the password of a keystore is read from, the file takes precedence.
+type KeyPassword struct {
+	File string
+	Env  string
+}
+
+// Read returns the password of the file, or of the environment variable if File is empty.
+func (p KeyPassword) Read() (string, error) {
+	if p.File != "" {
+		data, err := os.ReadFile(p.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password file, %v", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	if p.Env != "" {
+		password, ok := os.LookupEnv(p.Env)
+		if !ok {
+			return "", fmt.Errorf("password env %s not set", p.Env)
+		}
+		return password, nil
+	}
+
+	return "", errors.New("no keystore password given")
+}
+
+// PrivateKeyFromKeystore decrypts the private key of an encrypted keystore file.
+func PrivateKeyFromKeystore(keystoreFile string, password string) (*ecdsa.PrivateKey, error) {
+	keyjson, err := os.ReadFile(keystoreFile)
+	if err != nil {
+		return nil, err
 	}
 
-	key, e := keystore.DecryptKey(keyjson, password)
-	if e != nil {
-		panic(e)
+	key, err := keystore.DecryptKey(keyjson, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore %v, %v", keystoreFile, err)
 	}
 
-	e = crypto.SaveECDSA(outPath, key.PrivateKey)
-	if e != nil {
-		panic(e)
+	return key.PrivateKey, nil
+}
+
+// PrivateKeyFromFile reads the hex private key of a raw key file, e.g. saved by crypto.SaveECDSA.
+func PrivateKeyFromFile(keyFile string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := hexToECDSA(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key file %v, %v", keyFile, err)
+	}
+
+	return key, nil
+}
+
+// PrivateKeyFromEnv reads the hex private key of an environment variable.
+func PrivateKeyFromEnv(name string) (*ecdsa.PrivateKey, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("key env %s not set", name)
 	}
+
+	key, err := hexToECDSA(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key env %s, %v", name, err)
+	}
+
+	return key, nil
+}
+
+// LoadPrivateKey loads the private key of a spec, one of keystore:PATH, file:PATH or env:NAME.
+// The password of a keystore is read from password.
+func LoadPrivateKey(spec string, password KeyPassword) (*ecdsa.PrivateKey, error) {
+	kind, location, found := strings.Cut(spec, ":")
+	if !found || location == "" {
+		return nil, fmt.Errorf("invalid key %q, expected keystore:PATH, file:PATH or env:NAME", spec)
+	}
+
+	switch kind {
+	case "keystore":
+		pass, err := password.Read()
+		if err != nil {
+			return nil, err
+		}
+		return PrivateKeyFromKeystore(location, pass)
+	case "file":
+		return PrivateKeyFromFile(location)
+	case "env":
+		return PrivateKeyFromEnv(location)
+	default:
+		return nil, fmt.Errorf("invalid key %q, unknown source %s", spec, kind)
+	}
+}
+
+// LoadSecret reads a secret like a mnemonic of a spec, file:PATH or env:NAME, so it is not given on
+// the command line. The surrounding spaces of the secret are trimmed.
+func LoadSecret(spec string) (string, error) {
+	kind, location, found := strings.Cut(spec, ":")
+	if !found || location == "" {
+		return "", fmt.Errorf("invalid secret %q, expected file:PATH or env:NAME", spec)
+	}
+
+	switch kind {
+	case "file":
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	case "env":
+		value, ok := os.LookupEnv(location)
+		if !ok {
+			return "", fmt.Errorf("secret env %s not set", location)
+		}
+		return strings.TrimSpace(value), nil
+	default:
+		return "", fmt.Errorf("invalid secret %q, unknown source %s", spec, kind)
+	}
+}
+
+// hexToECDSA parses a hex private key, with or without 0x, ignoring surrounding spaces.
+func hexToECDSA(s string) (*ecdsa.PrivateKey, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	return crypto.HexToECDSA(s)
 }
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadPrivateKey(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.Nil(t, err)
+	dir := t.TempDir()
+
+	ks := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(key, "secret")
+	assert.Nil(t, err)
+	keystoreFile := account.URL.Path
+
+	passwordFile := filepath.Join(dir, "password")
+	assert.Nil(t, os.WriteFile(passwordFile, []byte("secret\n"), 0600))
+	keyFile := filepath.Join(dir, "key")
+	assert.Nil(t, os.WriteFile(keyFile, []byte("0x"+hex.EncodeToString(crypto.FromECDSA(key))+"\n"), 0600))
+	t.Setenv("TEST_KEY", hex.EncodeToString(crypto.FromECDSA(key)))
+	t.Setenv("TEST_PASSWORD", "secret")
+
+	for _, c := range []struct {
+		spec     string
+		password KeyPassword
+	}{
+		{"keystore:" + keystoreFile, KeyPassword{File: passwordFile}},
+		{"keystore:" + keystoreFile, KeyPassword{Env: "TEST_PASSWORD"}},
+		{"file:" + keyFile, KeyPassword{}},
+		{"env:TEST_KEY", KeyPassword{}},
+	} {
+		loaded, err := LoadPrivateKey(c.spec, c.password)
+		assert.Nil(t, err, c.spec)
+		assert.Equal(t, key.D, loaded.D, c.spec)
+	}
+
+	for _, c := range []struct {
+		spec     string
+		password KeyPassword
+	}{
+		{"", KeyPassword{}},
+		{keyFile, KeyPassword{}},
+		{"hex:" + hex.EncodeToString(crypto.FromECDSA(key)), KeyPassword{}},
+		{"keystore:" + keystoreFile, KeyPassword{}},
+		{"keystore:" + keystoreFile, KeyPassword{Env: "TEST_KEY"}},
+		{"file:" + keystoreFile, KeyPassword{}},
+		{"env:TEST_MISSING", KeyPassword{}},
+	} {
+		_, err := LoadPrivateKey(c.spec, c.password)
+		assert.NotNil(t, err, c.spec)
+	}
+}
+
+func TestLoadSecret(t *testing.T) {
+	mnemonic := "test test test test test test test test test test test junk"
+	file := filepath.Join(t.TempDir(), "mnemonic")
+	assert.Nil(t, os.WriteFile(file, []byte(mnemonic+"\n"), 0600))
+	t.Setenv("TEST_MNEMONIC", " "+mnemonic+" ")
+
+	for _, spec := range []string{"file:" + file, "env:TEST_MNEMONIC"} {
+		secret, err := LoadSecret(spec)
+		assert.Nil(t, err, spec)
+		assert.Equal(t, mnemonic, secret, spec)
+	}
+
+	for _, spec := range []string{"", mnemonic, "file:", "env:TEST_MISSING", "hex:0x01"} {
+		_, err := LoadSecret(spec)
+		assert.NotNil(t, err, spec)
+	}
+}